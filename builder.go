@@ -1,14 +1,19 @@
 package gqbuilder
 
 import (
+	"context"
 	"database/sql"
 )
 
 // Builder is a type
 type Builder struct {
-	driver databaseType
-	pool   *sql.DB
-	cmpl   compiler
+	driver   databaseType
+	pool     *sql.DB
+	cmpl     compiler
+	safeMode bool
+	stmts    *stmtCache
+	hooks    []Hook
+	cluster  *cluster
 }
 
 // NewBuilder return a Builder that had saved type of database driver
@@ -17,12 +22,167 @@ func NewBuilder(driver databaseType, db *sql.DB) *Builder {
 	bdr.driver = driver
 	bdr.pool = db
 	bdr.cmpl = compilerFactory(driver)
+	bdr.safeMode = true
 	return bdr
 }
 
+// Executor runs statements, *sql.DB, *sql.Conn and *sql.Tx are Executors
+type Executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// DB return the database of the builder, the primary of a cluster builder
+func (b *Builder) DB() *sql.DB {
+	return b.pool
+}
+
+// Dialect return the type of database the builder compiles for
+func (b *Builder) Dialect() databaseType {
+	return b.driver
+}
+
+// SetSafeMode turn safe mode on or off. In safe mode, which is on by default, an UPDATE or DELETE without
+// WHERE clause fails with ErrUnsafeWrite unless the query calls AllowFullTable
+func (b *Builder) SetSafeMode(on bool) *Builder {
+	b.safeMode = on
+	return b
+}
+
 // Query create a querier, which bound on a table
 func (b *Builder) Query(tableName string) *Query {
 	q := newQuery(b)
 	q.From(tableName)
 	return q
 }
+
+// ConditionCompiler return the ConditionCompiler used by queries of the builder
+func (b *Builder) ConditionCompiler() ConditionCompiler {
+	return b.cmpl.conditionCompiler()
+}
+
+// SetConditionCompiler replace the ConditionCompiler used by queries of the builder
+func (b *Builder) SetConditionCompiler(cc ConditionCompiler) *Builder {
+	b.cmpl.setConditionCompiler(cc)
+	return b
+}
+
+// WithBindPattern change how bind variables are written, e.g. WithBindPattern(Naming, ":") writes ":name"
+func (b *Builder) WithBindPattern(bp bindPattern, symbol string) *Builder {
+	b.cmpl.setBindPattern(bp, symbol)
+	return b
+}
+
+// Raw build a SQLResult from a hand-written statement. Bind variables written as "?", "$n", ":name" or
+// "@name" are rewritten in the builder's pattern; positional ones take args in order, named ones take
+// sql.NamedArg or NamedParam arguments, or stay parameters given at execution
+func (b *Builder) Raw(sqlText string, args ...interface{}) (*SQLResult, error) {
+	rst := b.cmpl.newResult()
+	raw, err := rebindArguments(sqlText, args, rst.args)
+	if err != nil {
+		return nil, &CompileError{"Raw", err}
+	}
+	rst.rawSQL = raw
+	return rst, nil
+}
+
+// EnableStmtCache keep up to size prepared statements keyed by sql text, Do, Get and Exec reuse them. The
+// least recently used statement is closed when the cache is full. A size <= 0 disables the cache
+func (b *Builder) EnableStmtCache(size int) *Builder {
+	if b.stmts != nil {
+		b.stmts.close()
+		b.stmts = nil
+	}
+	if size > 0 {
+		b.stmts = newStmtCache(size)
+	}
+	return b
+}
+
+// StmtCacheStats return the hit, miss and eviction counters of the statement cache
+func (b *Builder) StmtCacheStats() StmtCacheStats {
+	if b.stmts == nil {
+		return StmtCacheStats{}
+	}
+	return b.stmts.snapshot()
+}
+
+func (b *Builder) query(ctx context.Context, info stmtInfo, query string, args []interface{}) (*sql.Rows, error) {
+	var rows *sql.Rows
+	err := b.observe(ctx, opQuery, info, query, args, func(ctx context.Context) (int64, error) {
+		return -1, b.route(ctx, info, func(db *sql.DB) error {
+			var err error
+			rows, err = b.rawQuery(ctx, db, query, args)
+			return err
+		})
+	})
+	return rows, err
+}
+
+func (b *Builder) queryRow(ctx context.Context, info stmtInfo, query string, args []interface{}) (*sql.Row, error) {
+	var row *sql.Row
+	err := b.observe(ctx, opQueryRow, info, query, args, func(ctx context.Context) (int64, error) {
+		err := b.route(ctx, info, func(db *sql.DB) error {
+			var err error
+			if row, err = b.rawQueryRow(ctx, db, query, args); err != nil {
+				return err
+			}
+			// seen by route only, Scan returns the error of the row
+			return row.Err()
+		})
+		if row != nil {
+			err = nil
+		}
+		return -1, err
+	})
+	return row, err
+}
+
+func (b *Builder) exec(ctx context.Context, info stmtInfo, query string, args []interface{}) (sql.Result, error) {
+	var res sql.Result
+	err := b.observe(ctx, opExec, info, query, args, func(ctx context.Context) (int64, error) {
+		err := b.route(ctx, info, func(db *sql.DB) error {
+			var err error
+			res, err = b.rawExec(ctx, db, query, args)
+			return err
+		})
+		return rowsAffected(res, err), err
+	})
+	return res, err
+}
+
+func (b *Builder) rawQuery(ctx context.Context, db *sql.DB, query string, args []interface{}) (*sql.Rows, error) {
+	if b.stmts == nil {
+		return db.QueryContext(ctx, query, args...)
+	}
+	ent, err := b.stmts.acquire(ctx, db, query)
+	if err != nil {
+		return nil, err
+	}
+	defer b.stmts.release(ent)
+	return ent.stmt.QueryContext(ctx, args...)
+}
+
+func (b *Builder) rawQueryRow(ctx context.Context, db *sql.DB, query string, args []interface{}) (*sql.Row, error) {
+	if b.stmts == nil {
+		return db.QueryRowContext(ctx, query, args...), nil
+	}
+	ent, err := b.stmts.acquire(ctx, db, query)
+	if err != nil {
+		return nil, err
+	}
+	defer b.stmts.release(ent)
+	return ent.stmt.QueryRowContext(ctx, args...), nil
+}
+
+func (b *Builder) rawExec(ctx context.Context, db *sql.DB, query string, args []interface{}) (sql.Result, error) {
+	if b.stmts == nil {
+		return db.ExecContext(ctx, query, args...)
+	}
+	ent, err := b.stmts.acquire(ctx, db, query)
+	if err != nil {
+		return nil, err
+	}
+	defer b.stmts.release(ent)
+	return ent.stmt.ExecContext(ctx, args...)
+}
@@ -34,6 +34,13 @@ type columnClause struct {
 
 type rawColumnClause struct {
 	expression string
+	bindings   []interface{}
+	baseClause
+}
+
+type subColumnClause struct {
+	subQuery *Query
+	alias    string
 	baseClause
 }
 
@@ -62,14 +69,45 @@ type offsetClause struct {
 	baseClause
 }
 
+type lockClause struct {
+	baseClause
+}
+
 type orderByClause struct {
+	columnName   string
+	desc         bool
+	direction    string // as given to OrderByNulls, checked when compiled
+	nullsOrdered bool
+	nullsFirst   bool
+	baseClause
+}
+
+type randomOrderClause struct {
+	baseClause
+}
+
+type fieldOrderClause struct {
 	columnName string
-	desc       bool
+	values     []interface{}
 	baseClause
 }
 
 type groupByClause struct {
 	columnNames []string
+	kind        groupingKind
+	sets        [][]string
+	baseClause
+}
+
+type groupingColumnClause struct {
+	columnNames []string
+	alias       string
+	baseClause
+}
+
+type rawExprClause struct {
+	expression string
+	bindings   []interface{}
 	baseClause
 }
 
@@ -130,6 +168,12 @@ type existsCondition struct {
 
 type rawCodition struct {
 	expression string
+	bindings   []interface{}
+	conditionClause
+}
+
+type customCondition struct {
+	cond Condition
 	conditionClause
 }
 
@@ -141,6 +185,66 @@ type insertClause struct {
 }
 
 type updateClause struct {
-	item map[string]interface{}
+	columns []string
+	values  []interface{}
 	baseClause
 }
+
+// cloneElement return a deep copy of elm, slices and sub queries are not shared with the original
+func cloneElement(elm element) element {
+	switch cls := elm.(type) {
+	case groupByClause:
+		cls.columnNames = append([]string(nil), cls.columnNames...)
+		sets := make([][]string, 0, len(cls.sets))
+		for _, set := range cls.sets {
+			sets = append(sets, append([]string(nil), set...))
+		}
+		cls.sets = sets
+		return cls
+	case groupingColumnClause:
+		cls.columnNames = append([]string(nil), cls.columnNames...)
+		return cls
+	case inCondition:
+		cls.members = append([]interface{}(nil), cls.members...)
+		return cls
+	case inQueryCondition:
+		cls.subQuery = cls.subQuery.Clone()
+		return cls
+	case subColumnClause:
+		cls.subQuery = cls.subQuery.Clone()
+		return cls
+	case compareCondition:
+		if sub, ok := cls.value.(*Query); ok {
+			cls.value = sub.Clone()
+		}
+		return cls
+	case existsCondition:
+		cls.subQuery = cls.subQuery.Clone()
+		return cls
+	case insertClause:
+		cls.columns = append([]string(nil), cls.columns...)
+		cls.values = append([]interface{}(nil), cls.values...)
+		if cls.subQuery != nil {
+			cls.subQuery = cls.subQuery.Clone()
+		}
+		return cls
+	case fieldOrderClause:
+		cls.values = append([]interface{}(nil), cls.values...)
+		return cls
+	case rawColumnClause:
+		cls.bindings = append([]interface{}(nil), cls.bindings...)
+		return cls
+	case rawCodition:
+		cls.bindings = append([]interface{}(nil), cls.bindings...)
+		return cls
+	case rawExprClause:
+		cls.bindings = append([]interface{}(nil), cls.bindings...)
+		return cls
+	case updateClause:
+		cls.columns = append([]string(nil), cls.columns...)
+		cls.values = append([]interface{}(nil), cls.values...)
+		return cls
+	default:
+		return elm
+	}
+}
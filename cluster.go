@@ -0,0 +1,206 @@
+package gqbuilder
+
+/*
+	read/write splitting over a primary database and its replicas
+*/
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ReplicaStatus is the state of a replica of a cluster builder
+type ReplicaStatus struct {
+	DB      *sql.DB
+	Weight  int
+	Healthy bool
+	Reads   uint64
+}
+
+type replica struct {
+	db      *sql.DB
+	weight  int
+	current int // credit of the smooth weighted round-robin
+	healthy bool
+	reads   uint64
+}
+
+type cluster struct {
+	mu       sync.Mutex
+	replicas []*replica
+}
+
+// NewClusterBuilder return a Builder which runs writes on primary and spreads reads over replicas
+// round-robin. Reads are the SELECT statements run by Do and Get, except locking reads (ForUpdate) and
+// queries marked OnPrimary. Prepared queries, schema statements, Inspector and DB() use primary
+func NewClusterBuilder(driver databaseType, primary *sql.DB, replicas ...*sql.DB) *Builder {
+	bdr := NewBuilder(driver, primary)
+	bdr.cluster = new(cluster)
+	for _, db := range replicas {
+		bdr.cluster.replicas = append(bdr.cluster.replicas, &replica{db: db, weight: 1, healthy: true})
+	}
+	return bdr
+}
+
+// SetReplicaWeight give replica weight shares of the reads, replicas weigh 1 by default and a weight of 0
+// takes a replica out of the rotation
+func (b *Builder) SetReplicaWeight(replica *sql.DB, weight int) *Builder {
+	if b.cluster == nil {
+		return b
+	}
+	b.cluster.mu.Lock()
+	defer b.cluster.mu.Unlock()
+	for _, r := range b.cluster.replicas {
+		if r.db == replica {
+			r.weight = weight
+			r.current = 0
+		}
+	}
+	return b
+}
+
+// Replicas return the state of the replicas in the order given to NewClusterBuilder
+func (b *Builder) Replicas() []ReplicaStatus {
+	if b.cluster == nil {
+		return nil
+	}
+	b.cluster.mu.Lock()
+	defer b.cluster.mu.Unlock()
+	status := make([]ReplicaStatus, 0, len(b.cluster.replicas))
+	for _, r := range b.cluster.replicas {
+		status = append(status, ReplicaStatus{DB: r.db, Weight: r.weight, Healthy: r.healthy, Reads: r.reads})
+	}
+	return status
+}
+
+// CheckReplicas ping every replica and return the number of healthy ones. A replica failing the ping, or a
+// read failing to reach it, leaves the rotation until a ping succeeds again
+func (b *Builder) CheckReplicas(ctx context.Context) int {
+	if b.cluster == nil {
+		return 0
+	}
+	b.cluster.mu.Lock()
+	replicas := append([]*replica(nil), b.cluster.replicas...)
+	b.cluster.mu.Unlock()
+	n := 0
+	for _, r := range replicas {
+		ok := r.db.PingContext(ctx) == nil
+		b.cluster.setHealthy(r, ok)
+		if ok {
+			n++
+		}
+	}
+	return n
+}
+
+// StartHealthCheck run CheckReplicas every interval until ctx is done, interval must be positive
+func (b *Builder) StartHealthCheck(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("health check interval must be positive, got %s", interval)
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				b.CheckReplicas(ctx)
+			}
+		}
+	}()
+	return nil
+}
+
+func (c *cluster) setHealthy(r *replica, healthy bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r.healthy = healthy
+	if !healthy {
+		r.current = 0
+	}
+}
+
+// pick return the next healthy replica by smooth weighted round-robin, nil when there is none. Equal weights
+// take the replicas in turn
+func (c *cluster) pick() *replica {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var best *replica
+	total := 0
+	for _, r := range c.replicas {
+		if !r.healthy || r.weight <= 0 {
+			continue
+		}
+		r.current += r.weight
+		total += r.weight
+		if best == nil || r.current > best.current {
+			best = r
+		}
+	}
+	if best != nil {
+		best.current -= total
+		best.reads++
+	}
+	return best
+}
+
+type stickyKey struct{}
+
+// Sticky return a context under which a cluster builder reads from the primary once a write ran with it,
+// so a request sees its own writes however far the replicas lag
+func Sticky(ctx context.Context) context.Context {
+	return context.WithValue(ctx, stickyKey{}, new(int32))
+}
+
+func markWrite(ctx context.Context) {
+	if wrote, ok := ctx.Value(stickyKey{}).(*int32); ok {
+		atomic.StoreInt32(wrote, 1)
+	}
+}
+
+func wroteIn(ctx context.Context) bool {
+	wrote, ok := ctx.Value(stickyKey{}).(*int32)
+	return ok && atomic.LoadInt32(wrote) == 1
+}
+
+// route run fn on the database of the statement, a replica for the reads of a cluster builder and the
+// primary otherwise. A replica which can't be reached is evicted and fn run on the next one, the primary is
+// the last resort
+func (b *Builder) route(ctx context.Context, info stmtInfo, fn func(db *sql.DB) error) error {
+	if b.cluster == nil {
+		return fn(b.pool)
+	}
+	if info.method != selectMethod.String() {
+		markWrite(ctx)
+		return fn(b.pool)
+	}
+	if !info.primary && !wroteIn(ctx) {
+		for r := b.cluster.pick(); r != nil; r = b.cluster.pick() {
+			err := fn(r.db)
+			if !unreachable(ctx, err) {
+				return err
+			}
+			b.cluster.setHealthy(r, false)
+		}
+	}
+	return fn(b.pool)
+}
+
+// unreachable reports whether err is a failure of the connection rather than of the statement: a broken
+// connection, or a network error such as a refused dial or a reset, which is not caused by ctx ending
+func unreachable(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var nerr net.Error
+	return errors.Is(err, driver.ErrBadConn) || errors.As(err, &nerr)
+}
@@ -0,0 +1,179 @@
+package gqbuilder
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/paulnjiang/gqbuilder/internal/fakedb"
+)
+
+func TestClusterRouting(t *testing.T) {
+	ctx := context.Background()
+	primary, pdb := fakedb.New("primary")
+	r1, rdb1 := fakedb.New("r1")
+	r2, rdb2 := fakedb.New("r2")
+	bdr := NewClusterBuilder(MySQL, pdb, rdb1, rdb2)
+
+	for i := 0; i < 4; i++ {
+		rows, err := bdr.Query("user").Select("id").Get(ctx)
+		if err != nil {
+			t.Errorf("test cluster routing error: %s\n", err)
+			return
+		}
+		rows.Close()
+	}
+	if len(r1.Queries) != 2 || len(r2.Queries) != 2 || len(primary.Queries) != 0 {
+		t.Errorf("test cluster routing: expect round-robin, got %d %d %d\n", len(r1.Queries), len(r2.Queries), len(primary.Queries))
+	}
+
+	if _, err := bdr.Query("user").Where("id", "=", 1).Update(map[string]interface{}{"age": 3}).Exec(ctx); err != nil {
+		t.Errorf("test cluster routing error: %s\n", err)
+	}
+	row, err := bdr.Query("user").Select("id").Where("id", "=", 1).ForUpdate().Do(ctx)
+	if err != nil {
+		t.Errorf("test cluster routing error: %s\n", err)
+		return
+	}
+	row.Scan()
+	rows, err := bdr.Query("user").Select("id").OnPrimary().Get(ctx)
+	if err != nil {
+		t.Errorf("test cluster routing error: %s\n", err)
+		return
+	}
+	rows.Close()
+	if len(primary.Execs) != 1 || len(primary.Queries) != 2 || len(r1.Queries)+len(r2.Queries) != 4 {
+		t.Errorf("test cluster routing: writes and locking reads must use the primary, got %v %v\n", primary.Execs, primary.Queries)
+		return
+	}
+	if primary.Queries[0] != "SELECT `id` FROM `user` WHERE `id` = ? FOR UPDATE" {
+		t.Errorf("test cluster routing: unexpected %s\n", primary.Queries[0])
+	}
+
+	bdr.SetReplicaWeight(rdb1, 3)
+	for i := 0; i < 4; i++ {
+		row, err := bdr.Query("user").Select("id").Do(ctx)
+		if err != nil {
+			t.Errorf("test cluster routing error: %s\n", err)
+			return
+		}
+		row.Scan()
+	}
+	if len(r1.Queries) != 5 || len(r2.Queries) != 3 {
+		t.Errorf("test cluster routing: expect weighted reads, got %d %d\n", len(r1.Queries), len(r2.Queries))
+	}
+}
+
+func TestClusterSticky(t *testing.T) {
+	primary, pdb := fakedb.New("primary")
+	r1, rdb1 := fakedb.New("r1")
+	bdr := NewClusterBuilder(PostgreSQL, pdb, rdb1)
+	read := func(ctx context.Context) {
+		rows, err := bdr.Query("user").Select("id").Get(ctx)
+		if err != nil {
+			t.Errorf("test cluster sticky error: %s\n", err)
+			return
+		}
+		rows.Close()
+	}
+
+	ctx := Sticky(context.Background())
+	read(ctx)
+	if len(r1.Queries) != 1 {
+		t.Errorf("test cluster sticky: expect a replica read before writes\n")
+	}
+	bdr.Query("user").Insert([]string{"name"}, []interface{}{"bob"}).Exec(ctx)
+	read(ctx)
+	read(context.Background())
+	if len(primary.Queries) != 1 || len(r1.Queries) != 2 {
+		t.Errorf("test cluster sticky: expect primary after write, got %d %d\n", len(primary.Queries), len(r1.Queries))
+	}
+}
+
+func TestClusterEviction(t *testing.T) {
+	ctx := context.Background()
+	primary, pdb := fakedb.New("primary")
+	r1, rdb1 := fakedb.New("r1")
+	r2, rdb2 := fakedb.New("r2")
+	bdr := NewClusterBuilder(SQLite, pdb, rdb1, rdb2).EnableStmtCache(8)
+	read := func() {
+		rows, err := bdr.Query("user").Select("id").Get(ctx)
+		if err != nil {
+			t.Errorf("test cluster eviction error: %s\n", err)
+			return
+		}
+		rows.Close()
+	}
+
+	r1.SetDown(true)
+	read()
+	read()
+	if len(r2.Queries) != 2 || len(primary.Queries) != 0 {
+		t.Errorf("test cluster eviction: expect reads on r2, got %d %d\n", len(r2.Queries), len(primary.Queries))
+	}
+	if st := bdr.Replicas(); st[0].Healthy || !st[1].Healthy {
+		t.Errorf("test cluster eviction: expect r1 evicted, got %+v\n", st)
+	}
+	if n := bdr.CheckReplicas(ctx); n != 1 {
+		t.Errorf("test cluster eviction: expect 1 healthy replica, got %d\n", n)
+	}
+
+	r2.SetDown(true)
+	read()
+	if len(primary.Queries) != 1 {
+		t.Errorf("test cluster eviction: expect the primary without replicas\n")
+	}
+
+	r1.SetDown(false)
+	r2.SetDown(false)
+	if n := bdr.CheckReplicas(ctx); n != 2 {
+		t.Errorf("test cluster eviction: expect 2 healthy replicas, got %d\n", n)
+	}
+	read()
+	read()
+	if len(r1.Queries) != 1 || len(r2.Queries) != 3 {
+		t.Errorf("test cluster eviction: expect restored replicas, got %d %d\n", len(r1.Queries), len(r2.Queries))
+	}
+
+	if _, err := bdr.Query("user").Select("id").ForUpdate().Compile(); err == nil {
+		t.Errorf("test cluster eviction: expect FOR UPDATE unsupported on SQLite\n")
+	}
+}
+
+func TestClusterNetError(t *testing.T) {
+	ctx := context.Background()
+	primary, pdb := fakedb.New("primary")
+	r1, rdb1 := fakedb.New("r1")
+	bdr := NewClusterBuilder(SQLite, pdb, rdb1)
+
+	// what lib/pq returns when the replica refuses the connection
+	var fail error = &net.OpError{Op: "dial", Net: "tcp", Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 2), Port: 5432},
+		Err: &os.SyscallError{Syscall: "connect", Err: syscall.ECONNREFUSED}}
+	r1.Err = func(string) error { return fail }
+	if _, err := bdr.Query("user").Select("id").Get(ctx); err != nil {
+		t.Errorf("test cluster net error: %s\n", err)
+		return
+	}
+	if len(primary.Queries) != 1 || bdr.Replicas()[0].Healthy {
+		t.Errorf("test cluster net error: expect r1 evicted and the primary read, got %+v\n", bdr.Replicas())
+	}
+
+	bdr.CheckReplicas(ctx)
+	fail = errors.New("no such table: user")
+	if _, err := bdr.Query("user").Select("id").Get(ctx); err != fail {
+		t.Errorf("test cluster net error: expect the sql error, got %v\n", err)
+	}
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	bdr.Query("user").Select("id").Get(cctx)
+	if !bdr.Replicas()[0].Healthy || len(primary.Queries) != 1 {
+		t.Errorf("test cluster net error: expect r1 kept after a statement error or a cancel\n")
+	}
+
+	if err := bdr.StartHealthCheck(ctx, 0); err == nil {
+		t.Errorf("test cluster net error: expect an error for a zero interval\n")
+	}
+}
@@ -0,0 +1,91 @@
+package gqbcli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/paulnjiang/gqbuilder"
+)
+
+func runFmt(args []string) error {
+	fset := flag.NewFlagSet("fmt", flag.ExitOnError)
+	write := fset.Bool("w", false, "write the result to the file instead of stdout")
+	list := fset.Bool("l", false, "list the files whose formatting differs")
+	keywords := fset.String("case", "upper", "case of keywords: upper, lower or keep")
+	width := fset.Int("indent", 2, "spaces of an indentation level")
+	fset.Usage = func() {
+		fmt.Fprintf(fset.Output(), "usage: gqb fmt [flags] [file or directory ...]\n\nwith no paths, format stdin\n\n")
+		fset.PrintDefaults()
+	}
+	fset.Parse(args)
+
+	opts := gqbuilder.PrettyOptions{Indent: strings.Repeat(" ", *width)}
+	switch *keywords {
+	case "upper":
+		opts.Keywords = gqbuilder.UpperKeywords
+	case "lower":
+		opts.Keywords = gqbuilder.LowerKeywords
+	case "keep":
+		opts.Keywords = gqbuilder.KeepKeywords
+	default:
+		return fmt.Errorf("bad -case %q, want upper, lower or keep", *keywords)
+	}
+
+	if fset.NArg() == 0 {
+		src, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		fmt.Println(gqbuilder.FormatSQL(string(src), opts))
+		return nil
+	}
+	for _, path := range fset.Args() {
+		err := filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || (file != path && filepath.Ext(file) != ".sql") {
+				return nil
+			}
+			return fmtFile(file, opts, *write, *list)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fmtFile format a .sql file, print it or write it back
+func fmtFile(file string, opts gqbuilder.PrettyOptions, write, list bool) error {
+	src, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	res := gqbuilder.FormatSQL(string(src), opts) + "\n"
+	if list {
+		if res != string(src) {
+			fmt.Println(file)
+		}
+		if !write {
+			return nil
+		}
+	}
+	if write {
+		if res == string(src) {
+			return nil
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(file, []byte(res), info.Mode().Perm())
+	}
+	fmt.Print(res)
+	return nil
+}
@@ -0,0 +1,49 @@
+// Package gqbcli is the gqb command, importable so a main package of your module can link the database/sql
+// driver it uses:
+//
+//	package main
+//
+//	import (
+//		_ "github.com/lib/pq"
+//		"github.com/paulnjiang/gqbuilder/cmd/gqb/gqbcli"
+//	)
+//
+//	func main() { gqbcli.Main() }
+package gqbcli
+
+import (
+	"fmt"
+	"os"
+)
+
+const usage = `usage: gqb <command> [arguments]
+
+commands:
+	migrate   apply, roll back and list schema migrations
+	fmt       format .sql files, a clause per line
+`
+
+// Main run the command of os.Args and exit with its status
+func Main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	var err error
+	switch os.Args[1] {
+	case "migrate":
+		err = runMigrate(os.Args[2:])
+	case "fmt":
+		err = runFmt(os.Args[2:])
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "gqb: unknown command %q\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gqb:", err)
+		os.Exit(1)
+	}
+}
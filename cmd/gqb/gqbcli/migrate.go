@@ -0,0 +1,127 @@
+package gqbcli
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/paulnjiang/gqbuilder"
+	"github.com/paulnjiang/gqbuilder/migrate"
+)
+
+// dialects of the usual driver names
+var driverDialects = map[string]string{
+	"postgres": "postgres",
+	"pgx":      "postgres",
+	"mysql":    "mysql",
+	"sqlite3":  "sqlite",
+	"sqlite":   "sqlite",
+}
+
+func runMigrate(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	driverName := fs.String("driver", "postgres", "database/sql driver name")
+	dsn := fs.String("dsn", os.Getenv("DATABASE_URL"), "data source name, default $DATABASE_URL")
+	dialect := fs.String("dialect", "", "postgres, mysql or sqlite, default by driver name")
+	dir := fs.String("dir", "migrations", "directory of .sql migrations")
+	table := fs.String("table", migrate.DefaultTable, "table of applied versions")
+	timeout := fs.Duration("lock-timeout", time.Minute, "how long to wait for the migration lock")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: gqb migrate [flags] up [version] | down [steps] | status\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	if *dialect == "" {
+		*dialect = driverDialects[*driverName]
+	}
+	typ := gqbuilder.Standard
+	switch strings.ToLower(*dialect) {
+	case "postgres", "postgresql":
+		typ = gqbuilder.PostgreSQL
+	case "mysql":
+		typ = gqbuilder.MySQL
+	case "sqlite", "sqlite3":
+		typ = gqbuilder.SQLite
+	default:
+		return fmt.Errorf("unknown dialect %q, set -dialect", *dialect)
+	}
+	if !driverLinked(*driverName) {
+		return fmt.Errorf("driver %q is not linked, import it in a main package calling gqbcli.Main", *driverName)
+	}
+	db, err := sql.Open(*driverName, *dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	m := migrate.New(gqbuilder.NewBuilder(typ, db)).SetTable(*table).SetLockTimeout(*timeout)
+	if err := m.LoadFS(os.DirFS(*dir), "."); err != nil {
+		return err
+	}
+	ctx := context.Background()
+	switch fs.Arg(0) {
+	case "up":
+		version := int64(-1)
+		if fs.NArg() > 1 {
+			if version, err = strconv.ParseInt(fs.Arg(1), 10, 64); err != nil {
+				return fmt.Errorf("bad version %q", fs.Arg(1))
+			}
+		}
+		done, err := m.UpTo(ctx, version)
+		for _, v := range done {
+			fmt.Println("applied", v)
+		}
+		return err
+	case "down":
+		steps := 1
+		if fs.NArg() > 1 {
+			if steps, err = strconv.Atoi(fs.Arg(1)); err != nil || steps < 1 {
+				return fmt.Errorf("bad steps %q", fs.Arg(1))
+			}
+		}
+		done, err := m.Down(ctx, steps)
+		for _, v := range done {
+			fmt.Println("rolled back", v)
+		}
+		return err
+	case "status":
+		list, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, st := range list {
+			at := "pending"
+			if st.Applied {
+				at = st.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", st.Version, st.Name, at)
+		}
+		return w.Flush()
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+	return nil
+}
+
+func driverLinked(name string) bool {
+	for _, d := range sql.Drivers() {
+		if d == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,16 @@
+// Command gqb is the command line tool of gqbuilder.
+//
+//	gqb migrate [flags] up [version]
+//	gqb migrate [flags] down [steps]
+//	gqb migrate [flags] status
+//	gqb fmt [-w] [-l] [-case upper|lower|keep] [-indent n] [path ...]
+//
+// This build links no database/sql driver, so migrate only works through a main package of your own which
+// imports the driver and calls gqbcli.Main, see package gqbcli.
+package main
+
+import "github.com/paulnjiang/gqbuilder/cmd/gqb/gqbcli"
+
+func main() {
+	gqbcli.Main()
+}
@@ -0,0 +1,158 @@
+// Command gqbgen reads CREATE TABLE statements from .sql files and writes a Go package of typed tables and
+// columns for gqbuilder.
+//
+//	gqbgen -pkg schema -o schema/tables.go migrations/*.sql
+//
+// Every table "users" becomes a variable Users of type UsersTable, with a method per column returning a
+// gqbuilder.Column: schema.Users.Age().Gt(20)
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"os"
+	"sort"
+	"strings"
+)
+
+const importPath = "github.com/paulnjiang/gqbuilder"
+
+// methods of the generated table types, columns with the same Go name get a "Col" suffix
+var reserved = map[string]bool{"As": true, "Ref": true, "String": true}
+
+// common initialisms written in upper case
+var initialisms = map[string]bool{
+	"ID": true, "URL": true, "URI": true, "UUID": true, "IP": true, "HTTP": true, "JSON": true, "XML": true,
+	"API": true, "SQL": true, "HTML": true, "CPU": true, "TTL": true, "UID": true, "SKU": true,
+}
+
+func main() {
+	pkg := flag.String("pkg", "schema", "package name of the generated file")
+	out := flag.String("o", "", "output file, standard output if empty")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: gqbgen [-pkg name] [-o file] file.sql...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var tables []table
+	for _, path := range flag.Args() {
+		ddl, err := os.ReadFile(path)
+		if err != nil {
+			fatal(err)
+		}
+		tables = append(tables, parseDDL(string(ddl))...)
+	}
+	src, err := generate(*pkg, tables)
+	if err != nil {
+		fatal(err)
+	}
+	if *out == "" {
+		os.Stdout.Write(src)
+		return
+	}
+	if err := os.WriteFile(*out, src, 0644); err != nil {
+		fatal(err)
+	}
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "gqbgen:", err)
+	os.Exit(1)
+}
+
+// generate return the formatted source of the package
+func generate(pkg string, tables []table) ([]byte, error) {
+	sort.SliceStable(tables, func(i, j int) bool { return tables[i].name < tables[j].name })
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by gqbgen. DO NOT EDIT.\n\npackage %s\n\nimport %q\n", pkg, importPath)
+
+	seen := make(map[string]string)
+	for _, t := range tables {
+		base := t.name
+		if p := strings.LastIndex(base, "."); p >= 0 {
+			base = base[p+1:]
+		}
+		typ := goName(base)
+		if prev, ok := seen[typ]; ok {
+			return nil, fmt.Errorf("tables %q and %q have the same Go name %s", prev, t.name, typ)
+		}
+		seen[typ] = t.name
+		tt := typ + "Table"
+
+		fmt.Fprintf(&buf, "\n// %s is the table %q\n", typ, t.name)
+		fmt.Fprintf(&buf, "var %s = %s{gqbuilder.Table(%q)}\n", typ, tt, t.name)
+		fmt.Fprintf(&buf, "\n// %s is the typed table %q\n", tt, t.name)
+		fmt.Fprintf(&buf, "type %s struct {\n\tref gqbuilder.TableRef\n}\n", tt)
+		fmt.Fprintf(&buf, "\n// Ref return the gqbuilder.TableRef of the table\n")
+		fmt.Fprintf(&buf, "func (t %s) Ref() gqbuilder.TableRef {\n\treturn t.ref\n}\n", tt)
+		fmt.Fprintf(&buf, "\n// As return the table with an alias\n")
+		fmt.Fprintf(&buf, "func (t %s) As(alias string) %s {\n\treturn %s{t.ref.As(alias)}\n}\n", tt, tt, tt)
+		fmt.Fprintf(&buf, "\n// String return the table name as Builder.Query takes it\n")
+		fmt.Fprintf(&buf, "func (t %s) String() string {\n\treturn t.ref.String()\n}\n", tt)
+
+		cols := make(map[string]string)
+		for _, col := range t.columns {
+			name := goName(col)
+			if reserved[name] {
+				name += "Col"
+			}
+			if prev, ok := cols[name]; ok {
+				return nil, fmt.Errorf("columns %q and %q of table %q have the same Go name %s", prev, col, t.name, name)
+			}
+			cols[name] = col
+			fmt.Fprintf(&buf, "\n// %s is the column %q\n", name, col)
+			fmt.Fprintf(&buf, "func (t %s) %s() gqbuilder.Column {\n\treturn t.ref.Col(%q)\n}\n", tt, name, col)
+		}
+	}
+	return format.Source(buf.Bytes())
+}
+
+// goName return the exported Go name of a snake_case or camelCase sql name, e.g. "user_id" is UserID
+func goName(name string) string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = word[:0]
+		}
+	}
+	prevLower := false
+	for _, ch := range name {
+		isLetter := (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+		isDigit := ch >= '0' && ch <= '9'
+		if !isLetter && !isDigit {
+			flush()
+			prevLower = false
+			continue
+		}
+		if ch >= 'A' && ch <= 'Z' && prevLower {
+			flush()
+		}
+		word = append(word, ch)
+		prevLower = (ch >= 'a' && ch <= 'z') || isDigit
+	}
+	flush()
+
+	var buf strings.Builder
+	for _, w := range words {
+		up := strings.ToUpper(w)
+		if initialisms[up] {
+			buf.WriteString(up)
+			continue
+		}
+		buf.WriteString(up[:1] + strings.ToLower(w[1:]))
+	}
+	s := buf.String()
+	if s == "" || (s[0] >= '0' && s[0] <= '9') {
+		s = "T" + s
+	}
+	return s
+}
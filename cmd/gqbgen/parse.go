@@ -0,0 +1,208 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// table is a table read from a CREATE TABLE statement
+type table struct {
+	name    string
+	columns []string
+}
+
+// keywords that start a table constraint instead of a column definition
+var constraintWords = map[string]bool{
+	"CONSTRAINT": true,
+	"PRIMARY":    true,
+	"UNIQUE":     true,
+	"KEY":        true,
+	"INDEX":      true,
+	"FOREIGN":    true,
+	"CHECK":      true,
+	"FULLTEXT":   true,
+	"SPATIAL":    true,
+}
+
+// isConstraint reports whether the words of a definition are a table constraint. PERIOD and EXCLUDE are
+// common column names, they start a constraint only as "PERIOD FOR" and "EXCLUDE USING" or "EXCLUDE ("
+func isConstraint(words []string) bool {
+	first := strings.ToUpper(words[0])
+	next := ""
+	if len(words) > 1 {
+		next = strings.ToUpper(words[1])
+	}
+	switch {
+	case constraintWords[first]:
+		return true
+	case first == "PERIOD":
+		return next == "FOR"
+	case strings.HasPrefix(first, "EXCLUDE("):
+		return true
+	case first == "EXCLUDE":
+		return next == "USING" || strings.HasPrefix(next, "(")
+	}
+	return false
+}
+
+// parseDDL return the tables of the CREATE TABLE statements in ddl, other statements are skipped
+func parseDDL(ddl string) []table {
+	var tables []table
+	for _, stmt := range splitTop(stripComments(ddl), ';') {
+		words := fields(stmt)
+		i := 0
+		if !matchWords(words, &i, "CREATE") {
+			continue
+		}
+		matchWords(words, &i, "OR", "REPLACE")
+		if !matchWords(words, &i, "TEMPORARY") {
+			matchWords(words, &i, "TEMP")
+		}
+		matchWords(words, &i, "UNLOGGED")
+		if !matchWords(words, &i, "TABLE") {
+			continue
+		}
+		matchWords(words, &i, "IF", "NOT", "EXISTS")
+		if i >= len(words) {
+			continue
+		}
+		open := strings.Index(stmt, "(")
+		end := strings.LastIndex(stmt, ")")
+		if open < 0 || end < open {
+			continue
+		}
+		name := words[i]
+		if p := strings.Index(name, "("); p >= 0 {
+			name = name[:p]
+		}
+		t := table{name: unquoteName(name)}
+		for _, def := range splitTop(stmt[open+1:end], ',') {
+			def = strings.TrimSpace(def)
+			if def == "" {
+				continue
+			}
+			words := fields(def)
+			if isConstraint(words) {
+				continue
+			}
+			t.columns = append(t.columns, unquoteName(words[0]))
+		}
+		tables = append(tables, t)
+	}
+	return tables
+}
+
+// matchWords advance i past want if the words at i are want, case insensitive
+func matchWords(words []string, i *int, want ...string) bool {
+	if *i+len(want) > len(words) {
+		return false
+	}
+	for k, w := range want {
+		if !strings.EqualFold(words[*i+k], w) {
+			return false
+		}
+	}
+	*i += len(want)
+	return true
+}
+
+// fields split s by spaces outside of quotes
+func fields(s string) []string {
+	var words []string
+	var quote rune
+	start := -1
+	for i, ch := range s {
+		switch {
+		case quote != 0:
+			if ch == quote || (quote == '[' && ch == ']') {
+				quote = 0
+			}
+		case ch == '"' || ch == '`' || ch == '[' || ch == '\'':
+			quote = ch
+			if start < 0 {
+				start = i
+			}
+		case unicode.IsSpace(ch):
+			if start >= 0 {
+				words = append(words, s[start:i])
+				start = -1
+			}
+		default:
+			if start < 0 {
+				start = i
+			}
+		}
+	}
+	if start >= 0 {
+		words = append(words, s[start:])
+	}
+	return words
+}
+
+// splitTop split s by sep outside of parentheses and quotes
+func splitTop(s string, sep rune) []string {
+	var parts []string
+	var quote rune
+	depth := 0
+	last := 0
+	for i, ch := range s {
+		switch {
+		case quote != 0:
+			if ch == quote || (quote == '[' && ch == ']') {
+				quote = 0
+			}
+		case ch == '"' || ch == '`' || ch == '[' || ch == '\'':
+			quote = ch
+		case ch == '(':
+			depth++
+		case ch == ')':
+			depth--
+		case ch == sep && depth == 0:
+			parts = append(parts, s[last:i])
+			last = i + 1
+		}
+	}
+	return append(parts, s[last:])
+}
+
+// stripComments remove "--" and "/* */" comments outside of quotes
+func stripComments(s string) string {
+	var buf strings.Builder
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '"' || ch == '`':
+			quote = ch
+		case ch == '-' && i+1 < len(s) && s[i+1] == '-':
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+		case ch == '/' && i+1 < len(s) && s[i+1] == '*':
+			end := strings.Index(s[i+2:], "*/")
+			if end < 0 {
+				return buf.String()
+			}
+			i += end + 3
+			buf.WriteByte(' ')
+			continue
+		}
+		if i < len(s) {
+			buf.WriteByte(s[i])
+		}
+	}
+	return buf.String()
+}
+
+// unquoteName remove the quotes of every part of a qualified name
+func unquoteName(name string) string {
+	parts := strings.Split(name, ".")
+	for i, p := range parts {
+		parts[i] = strings.Trim(p, "\"`[]")
+	}
+	return strings.Join(parts, ".")
+}
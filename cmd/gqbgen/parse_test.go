@@ -0,0 +1,68 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseDDL(t *testing.T) {
+	ddl := `
+-- accounts of the site
+CREATE TABLE IF NOT EXISTS "users" (
+	"id" SERIAL PRIMARY KEY,
+	user_name VARCHAR(255) NOT NULL, /* login, unique */
+	score NUMERIC(10, 2) DEFAULT 0,
+	CONSTRAINT users_name UNIQUE (user_name)
+);
+CREATE INDEX users_score ON users (score);
+CREATE TABLE ` + "`shop`.`order_items`" + ` (
+	` + "`order_id`" + ` INT,
+	avatarURL TEXT,
+	name TEXT,
+	PRIMARY KEY (order_id)
+)`
+	tables := parseDDL(ddl)
+	if len(tables) != 2 {
+		t.Errorf("test parse ddl: expect 2 tables, got %v\n", tables)
+		return
+	}
+	if tables[0].name != "users" || strings.Join(tables[0].columns, ",") != "id,user_name,score" {
+		t.Errorf("test parse ddl: unexpected table %v\n", tables[0])
+	}
+	if tables[1].name != "shop.order_items" || strings.Join(tables[1].columns, ",") != "order_id,avatarURL,name" {
+		t.Errorf("test parse ddl: unexpected table %v\n", tables[1])
+	}
+
+	src, err := generate("schema", tables)
+	if err != nil {
+		t.Errorf("test generate error: %s\n", err)
+		return
+	}
+	for _, want := range []string{
+		"var OrderItems = OrderItemsTable{gqbuilder.Table(\"shop.order_items\")}",
+		"func (t UsersTable) UserName() gqbuilder.Column",
+		"func (t OrderItemsTable) AvatarURL() gqbuilder.Column",
+		"func (t OrderItemsTable) Name() gqbuilder.Column",
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("test generate: missing %q in\n%s\n", want, src)
+		}
+	}
+}
+
+func TestParseConstraintWords(t *testing.T) {
+	ddl := `CREATE TABLE bookings (
+	room INT,
+	period TSTZRANGE,
+	exclude BOOLEAN,
+	valid_from DATE,
+	valid_to DATE,
+	PERIOD FOR validity (valid_from, valid_to),
+	EXCLUDE USING gist (room WITH =, period WITH &&),
+	EXCLUDE (room WITH =)
+)`
+	tables := parseDDL(ddl)
+	if len(tables) != 1 || strings.Join(tables[0].columns, ",") != "room,period,exclude,valid_from,valid_to" {
+		t.Errorf("test parse constraint words: unexpected tables %v\n", tables)
+	}
+}
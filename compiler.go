@@ -6,7 +6,7 @@ package gqbuilder
 
 import (
 	"errors"
-	"reflect"
+	"fmt"
 	"strconv"
 	"strings"
 )
@@ -21,13 +21,54 @@ func (c *CompileError) Error() string {
 	return c.Function + ": " + c.Err.Error()
 }
 
-func (c *CompileError) Unwrap() string {
-	return c.Err.Error()
+func (c *CompileError) Unwrap() error {
+	return c.Err
+}
+
+// ErrUnsafeWrite is matched by errors.Is when safe mode rejects an UPDATE or DELETE without WHERE clause
+var ErrUnsafeWrite = errors.New("update or delete without WHERE clause")
+
+// UnsafeWriteError records the statement and table rejected by safe mode
+type UnsafeWriteError struct {
+	Statement string
+	Table     string
+}
+
+func (e *UnsafeWriteError) Error() string {
+	return e.Statement + " " + e.Table + ": " + ErrUnsafeWrite.Error()
+}
+
+// Is reports whether target is ErrUnsafeWrite
+func (e *UnsafeWriteError) Is(target error) bool {
+	return target == ErrUnsafeWrite
+}
+
+// ErrUnsupported is matched by errors.Is when a query uses a construct the dialect can't express
+var ErrUnsupported = errors.New("not supported by the dialect")
+
+// UnsupportedError records the construct and dialect that don't fit
+type UnsupportedError struct {
+	Dialect databaseType
+	Feature string
+}
+
+func (e *UnsupportedError) Error() string {
+	return e.Feature + " " + ErrUnsupported.Error() + " " + e.Dialect.String()
+}
+
+// Is reports whether target is ErrUnsupported
+func (e *UnsupportedError) Is(target error) bool {
+	return target == ErrUnsupported
 }
 
 type compiler interface {
 	compile(q *Query) (*SQLResult, error)
 	clone() compiler
+	conditionCompiler() ConditionCompiler
+	setConditionCompiler(cc ConditionCompiler)
+	setBindPattern(bp bindPattern, symbol string)
+	newResult() *SQLResult
+	base() *baseCompiler
 }
 
 func compilerFactory(engine databaseType) compiler {
@@ -44,39 +85,72 @@ func compilerFactory(engine databaseType) compiler {
 }
 
 type baseCompiler struct {
+	engine         databaseType
 	paramsPattern  bindPattern
 	symbolPrefix   string
 	leftIdentifier string
 	righIdentifier string
+	conditions     ConditionCompiler
 	result         *SQLResult
+	frames         []*Query
 }
 
 func newBaseCompiler() *baseCompiler {
 	c := new(baseCompiler)
+	c.engine = Standard
 	c.paramsPattern = PlaceHolder
 	c.symbolPrefix = "?"
 	c.leftIdentifier = "\""
 	c.righIdentifier = "\""
+	c.conditions = standardConditions{}
 	c.result = nil
 	return c
 }
 
+func (c *baseCompiler) base() *baseCompiler {
+	return c
+}
+
+// compile compile q as a whole statement. Sub queries are compiled by compileSubquery into their own
+// fragment, and every query of the statement binds its arguments to the same SQLResult
 func (c *baseCompiler) compile(q *Query) (*SQLResult, error) {
 	// first execute
 	if c.result == nil {
-		c.result = newSQLResult(c.paramsPattern, c.symbolPrefix)
+		c.result = c.newResult()
+	}
+	c.frames = nil
+	frag, err := c.compileQuery(q)
+	if err == nil && c.result.args.collision != "" {
+		err = &CompileError{"compile", fmt.Errorf("named parameter %q collides with a generated parameter name", c.result.args.collision)}
 	}
+	c.result.rawSQL = frag
+	return c.result, err
+}
+
+// compileQuery push q to the frame stack and compile it to a fragment
+func (c *baseCompiler) compileQuery(q *Query) (string, error) {
+	for _, f := range c.frames {
+		if f == q {
+			return "", &CompileError{"compile", errors.New("query contains itself as a sub query")}
+		}
+	}
+	c.frames = append(c.frames, q)
+	defer func() {
+		c.frames = c.frames[:len(c.frames)-1]
+	}()
 	switch q.method {
 	case selectMethod:
-		return c.result, c.CompileSelect(q)
+		return c.CompileSelect(q)
 	case insertMethod:
-		return c.result, c.CompileInsert(q)
+		return c.CompileInsert(q)
 	case updateMethod:
-		return c.result, c.CompileUpdate(q)
+		return c.CompileUpdate(q)
 	case deleteMethod:
-		return c.result, c.CompileDelete(q)
+		return c.CompileDelete(q)
+	case truncateMethod:
+		return c.CompileTruncate(q)
 	default:
-		return c.result, &CompileError{"compile: ", errors.New("query method type error")}
+		return "", &CompileError{"compile", errors.New("query method type error")}
 	}
 }
 
@@ -85,10 +159,76 @@ func (c *baseCompiler) clone() compiler {
 	return &cc
 }
 
+func (c *baseCompiler) conditionCompiler() ConditionCompiler {
+	return c.conditions
+}
+
+func (c *baseCompiler) setConditionCompiler(cc ConditionCompiler) {
+	c.conditions = cc
+}
+
+func (c *baseCompiler) newResult() *SQLResult {
+	return newSQLResult(c.paramsPattern, c.symbolPrefix)
+}
+
+func (c *baseCompiler) setBindPattern(bp bindPattern, symbol string) {
+	c.paramsPattern = bp
+	c.symbolPrefix = symbol
+}
+
+// checkSafeWrite reject an update or delete that has no WHERE clause when the builder is in safe mode
+func (c *baseCompiler) checkSafeWrite(q *Query, statement string, from fromClause) error {
+	if q.allowFullTable || q.builder == nil || !q.builder.safeMode {
+		return nil
+	}
+	if _, ok := q.getElement("where"); ok {
+		return nil
+	}
+	return &UnsafeWriteError{statement, from.tableName}
+}
+
+// compileSubquery compile q as a nested query, its arguments are bound to the outer statement
+func (c *baseCompiler) compileSubquery(q *Query) (string, error) {
+	if len(c.frames) == 0 {
+		return "", &CompileError{"compileSubquery", errors.New("no enclosing query")}
+	}
+	return c.compileQuery(q)
+}
+
 func (c *baseCompiler) wrapWord(word string) string {
+	if !strings.Contains(word, ".") {
+		return c.wrapPart(word)
+	}
+	parts := strings.Split(word, ".")
+	for i := range parts {
+		parts[i] = c.wrapPart(parts[i])
+	}
+	return strings.Join(parts, ".")
+}
+
+func (c *baseCompiler) wrapPart(word string) string {
+	if word == kwALL {
+		return word
+	}
 	return c.leftIdentifier + word + c.righIdentifier
 }
 
+// compileTable return a table name with its alias
+func (c *baseCompiler) compileTable(cls fromClause) string {
+	if cls.alias != "" {
+		return c.wrapWord(cls.tableName) + kwAS + c.wrapWord(cls.alias)
+	}
+	return c.wrapWord(cls.tableName)
+}
+
+// tableQualifier return the name that columns of table are qualified with
+func (c *baseCompiler) tableQualifier(cls fromClause) string {
+	if cls.alias != "" {
+		return cls.alias
+	}
+	return cls.tableName
+}
+
 func (c *baseCompiler) append(slc []string, str string, otherStrs ...string) []string {
 	if str != "" {
 		slc = append(slc, str)
@@ -107,48 +247,14 @@ func (c *baseCompiler) setArgument(v interface{}) string {
 }
 
 func (c *baseCompiler) CompileHaving(q *Query) (string, error) {
-	cpns, n := q.getElements("having")
-	if n == 0 {
-		return "", nil
+	body, err := c.compileConditions(q, "having")
+	if body == "" || err != nil {
+		return body, err
 	}
-	stmt := make([]string, 0, n*2)
-	stmt = append(stmt, kwHAVING)
-	for i := 0; i < n; i++ {
-		switch cond := cpns[i].(type) {
-		case compareCondition:
-			if i > 0 {
-				if cond.isOr {
-					stmt = append(stmt, kwOR)
-				} else {
-					stmt = append(stmt, kwAND)
-				}
-			}
-			s, err := c.CompileCompare(cpns[i])
-			if err != nil {
-				return "", &CompileError{"compileHaving", err}
-			}
-			stmt = append(stmt, s)
-		case rawCodition:
-			if i > 0 {
-				if cond.isOr {
-					stmt = append(stmt, kwOR)
-				} else {
-					stmt = append(stmt, kwAND)
-				}
-			}
-			raw, err := c.CompileRaw(cpns[i])
-			if err != nil {
-				return "", err
-			}
-			stmt = append(stmt, raw)
-		default:
-			continue
-		}
-	}
-	return strings.Join(stmt, kwSPACE), nil
+	return kwHAVING + kwSPACE + body, nil
 }
 
-func (c *baseCompiler) CompileSelect(q *Query) error {
+func (c *baseCompiler) CompileSelect(q *Query) (string, error) {
 	stmt := make([]string, 0, 16)
 	if q.isDistinct {
 		stmt = append(stmt, kwSELECT, kwDISTINCT)
@@ -157,72 +263,79 @@ func (c *baseCompiler) CompileSelect(q *Query) error {
 	}
 	rst, err := c.CompileColumns(q)
 	if err != nil {
-		return &CompileError{"CompileSelect: ", err}
+		return "", &CompileError{"CompileSelect", err}
 	}
 	if rst != "" {
 		stmt = append(stmt, rst)
 	}
 	rst, err = c.CompileFrom(q)
 	if err != nil {
-		return &CompileError{"CompileSelect: ", err}
+		return "", &CompileError{"CompileSelect", err}
 	}
 	if rst != "" {
 		stmt = append(stmt, rst)
 	}
 	rst, err = c.CompileJoins(q)
 	if err != nil {
-		return &CompileError{"CompileSelect: ", err}
+		return "", &CompileError{"CompileSelect", err}
 	}
 	if rst != "" {
 		stmt = append(stmt, rst)
 	}
 	rst, err = c.CompileWheres(q)
 	if err != nil {
-		return &CompileError{"CompileSelect: ", err}
+		return "", &CompileError{"CompileSelect", err}
 	}
 	if rst != "" {
 		stmt = append(stmt, rst)
 	}
 	rst, err = c.CompileGroupBy(q)
 	if err != nil {
-		return &CompileError{"CompileSelect: ", err}
+		return "", &CompileError{"CompileSelect", err}
 	}
 	if rst != "" {
 		stmt = append(stmt, rst)
 	}
 	rst, err = c.CompileHaving(q)
 	if err != nil {
-		return &CompileError{"CompileSelect: ", err}
+		return "", &CompileError{"CompileSelect", err}
 	}
 	if rst != "" {
 		stmt = append(stmt, rst)
 	}
 	rst, err = c.CompileOrderBy(q)
 	if err != nil {
-		return &CompileError{"CompileSelect: ", err}
+		return "", &CompileError{"CompileSelect", err}
 	}
 	if rst != "" {
 		stmt = append(stmt, rst)
 	}
 	rst, err = c.CompileLimit(q)
 	if err != nil {
-		return &CompileError{"CompileSelect: ", err}
+		return "", &CompileError{"CompileSelect", err}
 	}
 	if rst != "" {
 		stmt = append(stmt, rst)
 	}
 	rst, err = c.CompileOffset(q)
 	if err != nil {
-		return &CompileError{"CompileSelect: ", err}
+		return "", &CompileError{"CompileSelect", err}
 	}
 	if rst != "" {
 		stmt = append(stmt, rst)
 	}
-	c.result.rawSQL = strings.Join(stmt, kwSPACE)
-	return nil
+	rst, err = c.CompileLock(q)
+	if err != nil {
+		return "", &CompileError{"CompileSelect", err}
+	}
+	if rst != "" {
+		stmt = append(stmt, rst)
+	}
+	return strings.Join(stmt, kwSPACE), nil
 }
 
 func (c *baseCompiler) CompileColumns(q *Query) (string, error) {
+	ctx := &CompileContext{c}
 	clms := make([]string, 0)
 	types := []string{"column", "RawColumn"}
 	for _, t := range types {
@@ -240,13 +353,40 @@ func (c *baseCompiler) CompileColumns(q *Query) (string, error) {
 					}
 				case rawColumnClause:
 					cls := cpns[i].(rawColumnClause)
-					clms = append(clms, cls.expression)
+					expr, err := ctx.BindFragment(cls.expression, cls.bindings...)
+					if err != nil {
+						return "", &CompileError{"compileColumns", err}
+					}
+					clms = append(clms, expr)
+				case groupingColumnClause:
+					cls := cpns[i].(groupingColumnClause)
+					if c.engine == SQLite {
+						return "", &CompileError{"compileColumns", &UnsupportedError{c.engine, "GROUPING()"}}
+					}
+					if len(cls.columnNames) == 0 {
+						return "", &CompileError{"compileColumns", errors.New("GROUPING() without columns")}
+					}
+					col := "GROUPING(" + strings.Join(c.wrapWords(cls.columnNames), kwCOMMA) + ")"
+					if cls.alias != "" {
+						col = col + kwAS + c.wrapWord(cls.alias)
+					}
+					clms = append(clms, col)
+				case subColumnClause:
+					cls := cpns[i].(subColumnClause)
+					sub, err := ctx.Subquery(cls.subQuery)
+					if err != nil {
+						return "", err
+					}
+					clms = append(clms, "("+sub+")"+kwAS+c.wrapWord(cls.alias))
 				default:
 					continue
 				}
 			}
 		}
 	}
+	if len(clms) == 0 {
+		return kwALL, nil
+	}
 	return strings.Join(clms, kwCOMMA), nil
 }
 
@@ -304,69 +444,105 @@ func (c *baseCompiler) CompileJoin(cla joinClause) string {
 }
 
 func (c *baseCompiler) CompileWheres(q *Query) (string, error) {
-	cpns, n := q.getElements("where")
+	body, err := c.compileWhereBody(q)
+	if body == "" || err != nil {
+		return body, err
+	}
+	return kwWHERE + kwSPACE + body, nil
+}
+
+// compileWhereBody return the where conditions without WHERE keyword
+func (c *baseCompiler) compileWhereBody(q *Query) (string, error) {
+	return c.compileConditions(q, "where")
+}
+
+// compileConditions join the conditions named elementName with AND and OR
+func (c *baseCompiler) compileConditions(q *Query, elementName string) (string, error) {
+	cpns, n := q.getElements(elementName)
 	if n == 0 {
 		return "", nil
 	}
-
+	ctx := &CompileContext{c}
 	stmt := make([]string, 0, n*2)
-	stmt = append(stmt, kwWHERE)
-	refv := reflect.ValueOf(c)
-	if !refv.IsValid() {
-		panic("bad compiler(zero value)")
-	}
 	for i := 0; i < n; i++ {
-		cpnt := cpns[i]
+		cond, ok := cpns[i].(condition)
+		if !ok {
+			return "", &CompileError{"compileConditions", fmt.Errorf("unknown condition %T", cpns[i])}
+		}
 		if i > 0 {
-			isOr := reflect.ValueOf(cpnt).FieldByName("isOr")
-			if !isOr.IsValid() {
-				continue
-			}
-			if isOr.Bool() {
-				stmt = append(stmt, kwOR)
-			} else {
+			if cond.isAndOperator() {
 				stmt = append(stmt, kwAND)
+			} else {
+				stmt = append(stmt, kwOR)
 			}
 		}
-
-		clauseName := reflect.TypeOf(cpnt).Name()
-		title := strings.Title(strings.Replace(clauseName, "Condition", "", -1))
-		methodName := "Compile" + title
-		m := refv.MethodByName(methodName)
-		if !m.IsValid() {
-			continue
-		}
-		retVals := m.Call([]reflect.Value{reflect.ValueOf(cpnt)})
-		err := retVals[1].Interface()
-
+		s, err := cond.accept(ctx, c.conditions)
 		if err != nil {
-			switch err.(type) {
-			case error:
-				e := err.(error)
-				return strings.Join(stmt, kwSPACE), e
-			default:
-				return strings.Join(stmt, kwSPACE), &CompileError{"compileWheres: ", errors.New("unknow error")}
-			}
+			return "", err
 		}
-		stmt = append(stmt, retVals[0].String())
+		stmt = append(stmt, s)
 	}
 	return strings.Join(stmt, kwSPACE), nil
 }
 
 func (c *baseCompiler) CompileGroupBy(q *Query) (string, error) {
+	var cols []string
+	suffix := ""
 	if elm, ok := q.getElement("group"); ok {
 		cls, ok := elm.(groupByClause)
 		if !ok {
 			return "", &CompileError{"compileGroupBy", errors.New("assert error")}
 		}
-		n := len(cls.columnNames)
-		cols := make([]string, 0, n)
-		for i := 0; i < n; i++ {
-			cols = append(cols, c.wrapWord(cls.columnNames[i]))
+		switch cls.kind {
+		case rollupGrouping:
+			switch c.engine {
+			case MySQL:
+				cols = c.wrapWords(cls.columnNames)
+				suffix = kwSPACE + kwWITHROLLUP
+			case SQLite:
+				return "", &CompileError{"compileGroupBy", &UnsupportedError{c.engine, kwGROUPBY + kwSPACE + kwROLLUP}}
+			default:
+				cols = []string{kwROLLUP + " (" + strings.Join(c.wrapWords(cls.columnNames), kwCOMMA) + ")"}
+			}
+		case cubeGrouping:
+			if c.engine == MySQL || c.engine == SQLite {
+				return "", &CompileError{"compileGroupBy", &UnsupportedError{c.engine, kwGROUPBY + kwSPACE + kwCUBE}}
+			}
+			cols = []string{kwCUBE + " (" + strings.Join(c.wrapWords(cls.columnNames), kwCOMMA) + ")"}
+		case setsGrouping:
+			if c.engine == MySQL || c.engine == SQLite {
+				return "", &CompileError{"compileGroupBy", &UnsupportedError{c.engine, kwGROUPBY + kwSPACE + kwSETS}}
+			}
+			sets := make([]string, 0, len(cls.sets))
+			for _, set := range cls.sets {
+				sets = append(sets, "("+strings.Join(c.wrapWords(set), kwCOMMA)+")")
+			}
+			cols = []string{kwSETS + " (" + strings.Join(sets, kwCOMMA) + ")"}
+		default:
+			cols = c.wrapWords(cls.columnNames)
 		}
-		return kwGROUPBY + kwSPACE + strings.Join(cols, kwCOMMA), nil
 	}
-	return "", nil
+	raws, _ := q.getElements("groupRaw")
+	for _, elm := range raws {
+		cls := elm.(rawExprClause)
+		expr, err := bindFragment(cls.expression, cls.bindings, c.result.args)
+		if err != nil {
+			return "", &CompileError{"compileGroupBy", err}
+		}
+		cols = append(cols, expr)
+	}
+	if len(cols) == 0 {
+		return "", nil
+	}
+	return kwGROUPBY + kwSPACE + strings.Join(cols, kwCOMMA) + suffix, nil
+}
+
+func (c *baseCompiler) wrapWords(words []string) []string {
+	wrapped := make([]string, 0, len(words))
+	for _, w := range words {
+		wrapped = append(wrapped, c.wrapWord(w))
+	}
+	return wrapped
 }
 
 func (c *baseCompiler) CompileOrderBy(q *Query) (string, error) {
@@ -376,19 +552,73 @@ func (c *baseCompiler) CompileOrderBy(q *Query) (string, error) {
 	}
 	cols := make([]string, 0, n)
 	for i := 0; i < n; i++ {
-		cls, ok := cpns[i].(orderByClause)
-		if !ok {
+		switch cls := cpns[i].(type) {
+		case rawExprClause:
+			expr, err := bindFragment(cls.expression, cls.bindings, c.result.args)
+			if err != nil {
+				return "", &CompileError{"compileOrderBy", err}
+			}
+			cols = append(cols, expr)
+		case orderByClause:
+			if cls.nullsOrdered && !strings.EqualFold(cls.direction, kwASC) && !strings.EqualFold(cls.direction, kwDESC) {
+				return "", &CompileError{"compileOrderBy", fmt.Errorf("order direction %q of %s is not asc or desc", cls.direction, cls.columnName)}
+			}
+			cols = append(cols, c.compileOrder(cls))
+		case randomOrderClause:
+			if c.engine == MySQL {
+				cols = append(cols, "RAND()")
+			} else {
+				cols = append(cols, "RANDOM()")
+			}
+		case fieldOrderClause:
+			expr, err := c.compileFieldOrder(cls)
+			if err != nil {
+				return "", &CompileError{"compileOrderBy", err}
+			}
+			cols = append(cols, expr)
+		default:
 			return "", &CompileError{"compileOrderBy", errors.New("assert error")}
 		}
-		if cls.desc {
-			cols = append(cols, c.wrapWord(cls.columnName) + kwSPACE + kwDESC)
-		} else {
-			cols = append(cols, c.wrapWord(cls.columnName) + kwSPACE + kwASC)
-		}
 	}
 	return kwORDERBY + kwSPACE + strings.Join(cols, kwCOMMA), nil
 }
 
+func (c *baseCompiler) compileOrder(cls orderByClause) string {
+	col := c.wrapWord(cls.columnName)
+	dir := kwASC
+	if cls.desc {
+		dir = kwDESC
+	}
+	if !cls.nullsOrdered {
+		return col + kwSPACE + dir
+	}
+	if c.engine == MySQL {
+		// MySQL has no NULLS FIRST/LAST, ISNULL() is 1 for null values
+		if cls.nullsFirst {
+			return "ISNULL(" + col + ") " + kwDESC + kwCOMMA + col + kwSPACE + dir
+		}
+		return "ISNULL(" + col + ") " + kwASC + kwCOMMA + col + kwSPACE + dir
+	}
+	if cls.nullsFirst {
+		return col + kwSPACE + dir + kwSPACE + kwNULLSFIRST
+	}
+	return col + kwSPACE + dir + kwSPACE + kwNULLSLAST
+}
+
+// compileFieldOrder order rows by the position of column's value in a list, other values come last. MySQL's
+// FIELD() would put them first, every dialect gets the same CASE expression
+func (c *baseCompiler) compileFieldOrder(cls fieldOrderClause) (string, error) {
+	if len(cls.values) == 0 {
+		return "", errors.New("order by field of " + cls.columnName + " without values")
+	}
+	stmt := []string{"CASE", c.wrapWord(cls.columnName)}
+	for i, v := range cls.values {
+		stmt = append(stmt, "WHEN", c.setArgument(v), "THEN", strconv.Itoa(i))
+	}
+	stmt = append(stmt, "ELSE", strconv.Itoa(len(cls.values)), "END")
+	return strings.Join(stmt, kwSPACE), nil
+}
+
 func (c *baseCompiler) CompileLimit(q *Query) (string, error) {
 	elm, ok := q.getElement("limit")
 	if !ok {
@@ -426,240 +656,275 @@ func (c *baseCompiler) CompileOffset(q *Query) (string, error) {
 	return strings.Join(stmt, kwSPACE), nil
 }
 
-func (c *baseCompiler) CompileCompare(elm element) (string, error) {
-	cond, ok := elm.(compareCondition)
-	if !ok {
-		return "", &CompileError{"CompileCompare", errors.New("assert error")}
-	}
-	ph := c.setArgument(cond.value)
-
-	// TODO: check sign
-	stmt := []string{c.wrapWord(cond.columnName), cond.sign, ph}
-	return strings.Join(stmt, kwSPACE), nil
-}
-
-func (c *baseCompiler) CompileLike(elm element) (string, error) {
-	cond, ok := elm.(likeCondition)
-	if !ok {
-		return "", &CompileError{"CompileLike", errors.New("assert error")}
-	}
-	ph := c.setArgument(cond.like)
-	if cond.isNot {
-		stmt := []string{c.wrapWord(cond.columnName), kwNOT, kwLIKE, ph}
-		return strings.Join(stmt, kwSPACE), nil
-	}
-	stmt := []string{c.wrapWord(cond.columnName), kwLIKE, ph}
-	return strings.Join(stmt, kwSPACE), nil
-}
-
-func (c *baseCompiler) CompileColumnCompare(elm element) (string, error) {
-	cond, ok := elm.(columnCompareCondition)
-	if !ok {
-		return "", &CompileError{"CompileColumnCompare", errors.New("assert error")}
-	}
-	stmt := []string{c.wrapWord(cond.leftColumn), cond.sign, c.wrapWord(cond.rightColumn)}
-	return strings.Join(stmt, kwSPACE), nil
-}
-
-func (c *baseCompiler) CompileBetween(elm element) (string, error) {
-	cond, ok := elm.(betweenCondition)
-	if !ok {
-		return "", &CompileError{"CompileBetween", errors.New("assert error")}
-	}
-	f := c.setArgument(cond.from)
-	t := c.setArgument(cond.to)
-	if cond.isNot {
-		stmt := []string{c.wrapWord(cond.columnName), kwNOT, kwBETWEEN, f, kwAND, t}
-		return strings.Join(stmt, kwSPACE), nil
-	}
-	stmt := []string{c.wrapWord(cond.columnName), kwBETWEEN, f, kwAND, t}
-	return strings.Join(stmt, kwSPACE), nil
-}
-
-func (c *baseCompiler) CompileIn(elm element) (string, error) {
-	cond, ok := elm.(inCondition)
-	if !ok {
-		return "", &CompileError{"CompileIn", errors.New("assert error")}
-	}
-	var stmt []string
-	if cond.isNot {
-		stmt = []string{c.wrapWord(cond.columnName), kwNOT, kwIN, "("}
-	} else {
-		stmt = []string{c.wrapWord(cond.columnName), kwIN, "("}
-	}
-	var smbr []string
-	for _, mbr := range cond.members {
-		ph := c.setArgument(mbr)
-		smbr = append(smbr, ph)
-	}
-	stmt = append(stmt, strings.Join(smbr, kwCOMMA), ")")
-	return strings.Join(stmt, kwSPACE), nil
-}
-
-func (c *baseCompiler) CompileNull(elm element) (string, error) {
-	cond, ok := elm.(nullCondition)
-	if !ok {
-		return "", &CompileError{"CompileNull", errors.New("assert error")}
-	}
-	if cond.isNot {
-		stmt := []string{c.wrapWord(cond.columnName), kwIS, kwNOT, kwNULL}
-		return strings.Join(stmt, kwSPACE), nil
-	}
-	stmt := []string{c.wrapWord(cond.columnName), kwIS, kwNULL}
-	return strings.Join(stmt, kwSPACE), nil
-}
-
-func (c *baseCompiler) CompileBoolean(elm element) (string, error) {
-	cond, ok := elm.(booleanCondition)
-	if !ok {
-		return "", &CompileError{"CompileBoolean", errors.New("assert error")}
-	}
-	if cond.isNot {
-		stmt := []string{c.wrapWord(cond.columnName), "=", kwFALSE}
-		return strings.Join(stmt, kwSPACE), nil
-	}
-	stmt := []string{c.wrapWord(cond.columnName), "=", kwTRUE}
-	return strings.Join(stmt, kwSPACE), nil
-}
-
-func (c *baseCompiler) CompileRaw(elm element) (string, error) {
-	cond, ok := elm.(rawCodition)
-	if !ok {
-		return "", &CompileError{"CompileRaw", errors.New("assert error")}
-	}
-	return cond.expression, nil
-}
-
-func (c *baseCompiler) CompileExists(elm element) (string, error) {
-	cond, ok := elm.(existsCondition)
-	if !ok {
-		return "", &CompileError{"CompileExists", errors.New("assert error")}
-	}
-	rst, err := c.compile(cond.subQuery)
-	if err != nil {
-		return "", err
+// CompileLock compile the row lock of a locking read, SQLite locks the whole database and has none
+func (c *baseCompiler) CompileLock(q *Query) (string, error) {
+	if _, ok := q.getElement("lock"); !ok {
+		return "", nil
 	}
-	subq := rst.rawSQL
-	if cond.isNot {
-		return kwNOT + kwSPACE + kwEXISTS + " (" + subq + ") ", nil
+	if c.engine == SQLite {
+		return "", &CompileError{"compileLock", &UnsupportedError{c.engine, kwFORUPDATE}}
 	}
-	return kwEXISTS + " (" + subq + ") ", nil
+	return kwFORUPDATE, nil
 }
 
-func (c *baseCompiler) CompileInQuery(elm element) (string, error) {
-	stmt := make([]string, 0, 8)
-	cond, ok := elm.(inQueryCondition)
-	if !ok {
-		return "", &CompileError{"CompileInQuery", errors.New("assert error")}
-	}
-	stmt = append(stmt, c.wrapWord(cond.columnName))
-	rst, err := c.compile(cond.subQuery)
-	if err != nil {
-		return "", err
-	}
-	subq := "(" + rst.rawSQL + ")"
-	if cond.isNot {
-		stmt = append(stmt, kwNOT, kwIN, subq)
-	} else {
-		stmt = append(stmt, kwIN, subq)
-	}
-	return strings.Join(stmt, kwSPACE), nil
-}
-
-func (c *baseCompiler) CompileInsert(q *Query) error {
+func (c *baseCompiler) CompileInsert(q *Query) (string, error) {
 	var elm element
 	stmt := []string{kwINSERT}
 	elm, has := q.getElement("from")
 	if !has {
-		return &CompileError{"compileInsert", errors.New("no table specified")}
+		return "", &CompileError{"compileInsert", errors.New("no table specified")}
 	}
 	tableName := c.wrapWord(elm.(fromClause).tableName)
 	stmt = append(stmt, tableName)
 
 	elm, has = q.getElement("insert")
 	if !has {
-		return &CompileError{"compileInsert", errors.New("insert clause not exists")}
+		return "", &CompileError{"compileInsert", errors.New("insert clause not exists")}
 	}
 	ic := elm.(insertClause)
 
-	// from sub query
-	if ic.subQuery != nil {
-		str, e := ic.subQuery.ToString()
-		if e != nil {
-			return &CompileError{"compileInsert", e}
-		} 
-		stmt = append(stmt, str)
-		
-		c.result.rawSQL = strings.Join(stmt, kwSPACE)
-		return nil
-	}
-
 	// name values OR only value
 	if len(ic.columns) != 0 {
-		cols := "(" + c.leftIdentifier
-		cols = cols + strings.Join(ic.columns, c.leftIdentifier + kwCOMMA + c.righIdentifier)
-		cols = cols + c.righIdentifier + ")"
-		stmt = append(stmt, cols, kwVALUES)
-	} else {
-		stmt = append(stmt, kwVALUES)
+		cols := make([]string, 0, len(ic.columns))
+		for _, col := range ic.columns {
+			cols = append(cols, c.wrapWord(col))
+		}
+		stmt = append(stmt, "("+strings.Join(cols, kwCOMMA)+")")
 	}
 
+	// from sub query, compiled by this dialect and bound to the outer statement
+	if ic.subQuery != nil {
+		sub, err := c.compileSubquery(ic.subQuery)
+		if err != nil {
+			return "", &CompileError{"compileInsert", err}
+		}
+		stmt = append(stmt, sub)
+		return strings.Join(stmt, kwSPACE), nil
+	}
+	stmt = append(stmt, kwVALUES)
+
 	// replace value to placeholder
 	pls := make([]string, 0, len(ic.values))
 	for _, v := range ic.values {
 		pls = append(pls, c.setArgument(v))
 	}
 	stmt = append(stmt, "("+strings.Join(pls, kwCOMMA)+")")
-	c.result.rawSQL = strings.Join(stmt, kwSPACE)
-	return nil
+	return strings.Join(stmt, kwSPACE), nil
 }
 
-func (c *baseCompiler) CompileUpdate(q *Query) error {
+func (c *baseCompiler) CompileUpdate(q *Query) (string, error) {
 	var elm element
-	stmt := []string{kwUPDATE}
 	elm, has := q.getElement("from")
 	if !has {
-		return &CompileError{"compileUpdate", errors.New("no table specified")}
+		return "", &CompileError{"compileUpdate", errors.New("no table specified")}
 	}
-	tableName := c.wrapWord(elm.(fromClause).tableName)
-	stmt = append(stmt, tableName, kwSET)
+	from := elm.(fromClause)
+	if err := c.checkSafeWrite(q, kwUPDATE, from); err != nil {
+		return "", &CompileError{"compileUpdate", err}
+	}
+	stmt := []string{kwUPDATE, c.compileTable(from)}
 
 	elm, has = q.getElement("update")
 	if !has {
-		return &CompileError{"compileUpdate", errors.New("update clause not exists")}
+		return "", &CompileError{"compileUpdate", errors.New("update clause not exists")}
 	}
 	cls := elm.(updateClause)
-	pairs := make([]string, 0)
-	for clm, v := range cls.item {
-		s := c.wrapWord(clm) + "=" + c.setArgument(v)
+	mode := c.writeMode(q)
+	if mode == subqueryWriteJoin {
+		// the rewritten statement updates the bare table
+		stmt = []string{kwUPDATE, c.wrapWord(from.tableName)}
+	}
+	if mode == inlineWriteJoin {
+		joins, err := c.CompileJoins(q)
+		if err != nil {
+			return "", &CompileError{"compileUpdate", err}
+		}
+		stmt = append(stmt, joins)
+	}
+
+	pairs := make([]string, 0, len(cls.columns))
+	for i, clm := range cls.columns {
+		if c.engine != MySQL {
+			// only MySQL accepts qualified column names in SET
+			clm = strings.TrimPrefix(clm, c.tableQualifier(from)+".")
+		}
+		s := c.wrapWord(clm) + "=" + c.setArgument(cls.values[i])
 		pairs = append(pairs, s)
 	}
-	stmt = append(stmt, strings.Join(pairs, kwCOMMA))
+	stmt = append(stmt, kwSET, strings.Join(pairs, kwCOMMA))
 
 	// compile condition clause
-	whe, err := c.CompileWheres(q)
+	whe, err := c.compileWriteWheres(q, from, mode)
 	if err != nil {
-		return err
+		return "", &CompileError{"compileUpdate", err}
+	}
+	stmt = c.append(stmt, whe)
+	if mode == noWriteJoin {
+		if stmt, err = c.appendWriteLimit(stmt, q); err != nil {
+			return "", &CompileError{"compileUpdate", err}
+		}
 	}
-	stmt = append(stmt, whe)
-	c.result.rawSQL = strings.Join(stmt, kwSPACE)
-	return nil
+	return strings.Join(stmt, kwSPACE), nil
 }
 
-func (c *baseCompiler) CompileDelete(q *Query) error {
+func (c *baseCompiler) CompileDelete(q *Query) (string, error) {
 	var elm element
 	stmt := []string{kwDELETE}
 	elm, has := q.getElement("from")
 	if !has {
-		return &CompileError{"compileUpdate", errors.New("no table specified")}
+		return "", &CompileError{"compileDelete", errors.New("no table specified")}
+	}
+	from := elm.(fromClause)
+	if err := c.checkSafeWrite(q, kwDELETEJOIN, from); err != nil {
+		return "", &CompileError{"compileDelete", err}
+	}
+	mode := c.writeMode(q)
+	switch mode {
+	case inlineWriteJoin:
+		joins, err := c.CompileJoins(q)
+		if err != nil {
+			return "", &CompileError{"compileDelete", err}
+		}
+		stmt = []string{kwDELETEJOIN, c.wrapWord(c.tableQualifier(from)), kwFROM, c.compileTable(from), joins}
+	case subqueryWriteJoin:
+		stmt = append(stmt, c.wrapWord(from.tableName))
+	default:
+		stmt = append(stmt, c.compileTable(from))
+	}
+	whe, err := c.compileWriteWheres(q, from, mode)
+	if err != nil {
+		return "", &CompileError{"compileDelete", err}
+	}
+	stmt = c.append(stmt, whe)
+	if mode == noWriteJoin {
+		if stmt, err = c.appendWriteLimit(stmt, q); err != nil {
+			return "", &CompileError{"compileDelete", err}
+		}
+	}
+	return strings.Join(stmt, kwSPACE), nil
+}
+
+func (c *baseCompiler) CompileTruncate(q *Query) (string, error) {
+	elm, has := q.getElement("from")
+	if !has {
+		return "", &CompileError{"compileTruncate", errors.New("no table specified")}
 	}
 	tableName := c.wrapWord(elm.(fromClause).tableName)
-	stmt = append(stmt, tableName)
-	whe, err := c.CompileWheres(q)
+	if c.engine == SQLite {
+		// SQLite has no TRUNCATE, an unconditional DELETE is optimized to the same
+		return kwDELETE + kwSPACE + tableName, nil
+	}
+	return kwTRUNCATE + kwSPACE + tableName, nil
+}
+
+// writeMode choose how the joins, ORDER BY and LIMIT of an update or delete statement are written in this
+// dialect
+func (c *baseCompiler) writeMode(q *Query) writeJoin {
+	joins, n := q.getElements("join")
+	_, ordered := q.getElement("order")
+	_, limited := q.getElement("limit")
+	_, offset := q.getElement("offset")
+	switch c.engine {
+	case MySQL:
+		// ORDER BY and LIMIT are allowed on single table statements only
+		if offset || (n != 0 && (ordered || limited)) {
+			return subqueryWriteJoin
+		}
+		if n == 0 {
+			return noWriteJoin
+		}
+		return inlineWriteJoin
+	case PostgreSQL:
+		if ordered || limited || offset {
+			return subqueryWriteJoin
+		}
+		if n == 0 {
+			return noWriteJoin
+		}
+		// FROM and USING can only express inner joins
+		for _, elm := range joins {
+			if elm.(joinClause).joinTyp != innerJoin {
+				return subqueryWriteJoin
+			}
+		}
+		return fromWriteJoin
+	default:
+		if n == 0 && !ordered && !limited && !offset {
+			return noWriteJoin
+		}
+		return subqueryWriteJoin
+	}
+}
+
+// appendWriteLimit append ORDER BY and LIMIT of a single table update or delete statement
+func (c *baseCompiler) appendWriteLimit(stmt []string, q *Query) ([]string, error) {
+	order, err := c.CompileOrderBy(q)
+	if err != nil {
+		return stmt, err
+	}
+	limit, err := c.CompileLimit(q)
 	if err != nil {
-		return err
+		return stmt, err
+	}
+	return c.append(stmt, order, limit), nil
+}
+
+// compileWriteWheres compile the WHERE clause of an update or delete statement, together with the join
+// constraints that the dialect can't write inline
+func (c *baseCompiler) compileWriteWheres(q *Query, from fromClause, mode writeJoin) (string, error) {
+	switch mode {
+	case fromWriteJoin:
+		joins, _ := q.getElements("join")
+		tables := make([]string, 0, len(joins))
+		conds := make([]string, 0, len(joins)+1)
+		for _, elm := range joins {
+			cls := elm.(joinClause)
+			tables = append(tables, c.wrapWord(cls.table))
+			conds = append(conds, c.wrapWord(cls.left)+kwSPACE+cls.sign+kwSPACE+c.wrapWord(cls.right))
+		}
+		body, err := c.compileWhereBody(q)
+		if err != nil {
+			return "", err
+		}
+		if body != "" {
+			conds = append(conds, "("+body+")")
+		}
+		kw := kwFROM
+		if q.method == deleteMethod {
+			kw = kwUSING
+		}
+		return kw + kwSPACE + strings.Join(tables, kwCOMMA) + kwSPACE +
+			kwWHERE + kwSPACE + strings.Join(conds, kwSPACE+kwAND+kwSPACE), nil
+	case subqueryWriteJoin:
+		stmt := []string{kwSELECT, c.wrapWord(c.tableQualifier(from) + "." + q.keyColumn), kwFROM, c.compileTable(from)}
+		joins, err := c.CompileJoins(q)
+		if err != nil {
+			return "", err
+		}
+		whe, err := c.CompileWheres(q)
+		if err != nil {
+			return "", err
+		}
+		order, err := c.CompileOrderBy(q)
+		if err != nil {
+			return "", err
+		}
+		limit, err := c.CompileLimit(q)
+		if err != nil {
+			return "", err
+		}
+		offset, err := c.CompileOffset(q)
+		if err != nil {
+			return "", err
+		}
+		stmt = c.append(stmt, joins, whe, order, limit, offset)
+		sub := strings.Join(stmt, kwSPACE)
+		if c.engine == MySQL {
+			// MySQL can't read the target table nor use LIMIT in an IN sub query, a derived table hides both
+			sub = kwSELECT + kwSPACE + c.wrapWord(q.keyColumn) + kwSPACE + kwFROM + " (" + sub + ")" + kwAS + c.wrapWord("gqb_keys")
+		}
+		return kwWHERE + kwSPACE + c.wrapWord(q.keyColumn) + kwSPACE + kwIN + " (" + sub + ")", nil
+	default:
+		return c.CompileWheres(q)
 	}
-	stmt = append(stmt, whe)
-	c.result.rawSQL = strings.Join(stmt, kwSPACE)
-	return nil
 }
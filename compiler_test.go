@@ -0,0 +1,144 @@
+package gqbuilder
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"testing"
+)
+
+func benchmarkQuery() *Query {
+	var con *sql.DB
+	bdr := NewBuilder(dbtype, con)
+	return bdr.Query("user").Select("id", "name").
+		Where("age", ">", 20).
+		WhereLike("name", "%bob%").
+		Between("score", 10, 90).
+		WhereIn("city", "NYC", "LA", "SF").
+		OrWhereNull("deleted_at")
+}
+
+func BenchmarkCompileWheres(b *testing.B) {
+	q := benchmarkQuery()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cmpl := q.builder.cmpl.clone().(*baseCompiler)
+		cmpl.result = newSQLResult(cmpl.paramsPattern, cmpl.symbolPrefix)
+		if _, err := cmpl.CompileWheres(q); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCompileSelect(b *testing.B) {
+	q := benchmarkQuery()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := q.ToPrepared(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+type ilikeConditions struct {
+	ConditionCompiler
+}
+
+func (ilikeConditions) CompileLike(ctx *CompileContext, column, pattern string, not bool) (string, error) {
+	return ctx.Quote(column) + " ILIKE " + ctx.Bind(pattern), nil
+}
+
+func TestConditionCompiler(t *testing.T) {
+	var con *sql.DB
+	bdr := NewBuilder(PostgreSQL, con)
+	bdr.SetConditionCompiler(ilikeConditions{bdr.ConditionCompiler()})
+	raw, _, e := bdr.Query("user").Select("id").Where("age", ">", 20).WhereLike("name", "%bob%").ToPrepared()
+	if e != nil {
+		t.Errorf("test condition compiler error: %s\n", e)
+		return
+	}
+	if raw != `SELECT "id" FROM "user" WHERE "age" > $1 AND "name" ILIKE $2` {
+		t.Errorf("test condition compiler: unexpected sql %s\n", raw)
+	}
+}
+
+func TestNotCompare(t *testing.T) {
+	var con *sql.DB
+	bdr := NewBuilder(PostgreSQL, con)
+	users := Table("user")
+	raw, _, e := bdr.Query("user").Select("id").Not().Where("age", ">", 20).
+		Not().WhereCond(users.Col("a").EqCol(users.Col("b"))).Not().WhereRaw("a > b").
+		GroupBy("a").Not().HavingRaw("count(*) > ?", 1).ToPrepared()
+	if e != nil {
+		t.Errorf("test not compare error: %s\n", e)
+		return
+	}
+	// comparisons and raw conditions ignore Not() as they always did
+	if raw != `SELECT "id" FROM "user" WHERE "age" > $1 AND "user"."a" = "user"."b" AND a > b GROUP BY "a" HAVING count(*) > $2` {
+		t.Errorf("test not compare: unexpected sql %s\n", raw)
+	}
+}
+
+type unknownClause struct {
+	baseClause
+}
+
+func TestUnknownCondition(t *testing.T) {
+	var con *sql.DB
+	bdr := NewBuilder(dbtype, con)
+	q := bdr.Query("user").Where("age", ">", 20)
+	q.addElement(unknownClause{baseClause{"where"}})
+	if _, e := q.ToString(); e == nil {
+		t.Errorf("test unknown condition: expect an error\n")
+	}
+}
+
+type tenantCondition struct {
+	tenant int
+}
+
+func (c tenantCondition) CompileCondition(ctx *CompileContext) (string, error) {
+	return ctx.Quote("tenant_id") + " = " + ctx.Bind(c.tenant), nil
+}
+
+func TestCustomCondition(t *testing.T) {
+	var con *sql.DB
+	bdr := NewBuilder(PostgreSQL, con)
+	q := bdr.Query("user").Select("id").Where("age", ">", 20).WhereCond(tenantCondition{7}).Not().OrWhereCond(tenantCondition{8})
+	raw, args, e := q.ToPrepared()
+	if e != nil {
+		t.Errorf("test custom condition error: %s\n", e)
+		return
+	}
+	if raw != `SELECT "id" FROM "user" WHERE "age" > $1 AND ("tenant_id" = $2) OR NOT ("tenant_id" = $3)` || len(args) != 3 || args[1] != 7 {
+		t.Errorf("test custom condition: unexpected sql %s %v\n", raw, args)
+	}
+
+	raw, _, e = bdr.Query("user").Select("id").Where("x", "=", 1).WhereCond(eitherCondition{}).ToPrepared()
+	if e != nil {
+		t.Errorf("test custom condition error: %s\n", e)
+		return
+	}
+	if raw != `SELECT "id" FROM "user" WHERE "x" = $1 AND ("a" = $2 OR "b" = $3)` {
+		t.Errorf("test custom condition: unexpected sql of an OR condition %s\n", raw)
+	}
+}
+
+type eitherCondition struct{}
+
+func (eitherCondition) CompileCondition(ctx *CompileContext) (string, error) {
+	return ctx.Quote("a") + " = " + ctx.Bind(1) + " OR " + ctx.Quote("b") + " = " + ctx.Bind(2), nil
+}
+
+func TestCompileErrorUnwrap(t *testing.T) {
+	err := error(&CompileError{"compileTest", io.EOF})
+	if errors.Unwrap(err) != io.EOF || !errors.Is(err, io.EOF) {
+		t.Errorf("test compile error unwrap: expect io.EOF, got %v\n", errors.Unwrap(err))
+	}
+	wrapped := &CompileError{"compileOuter", err}
+	if !errors.Is(wrapped, io.EOF) {
+		t.Errorf("test compile error unwrap: nested error lost\n")
+	}
+}
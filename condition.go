@@ -0,0 +1,245 @@
+package gqbuilder
+
+/*
+	compile conditions of WHERE and HAVING clauses
+*/
+
+import (
+	"strings"
+)
+
+// CompileContext gives a ConditionCompiler access to the statement being compiled
+type CompileContext struct {
+	cmpl *baseCompiler
+}
+
+// Quote wrap an identifier with the dialect's quotes, a qualified name like "user.id" is wrapped part by part
+func (ctx *CompileContext) Quote(identifier string) string {
+	return ctx.cmpl.wrapWord(identifier)
+}
+
+// Bind add a value to the statement's arguments and return its placeholder
+func (ctx *CompileContext) Bind(value interface{}) string {
+	return ctx.cmpl.setArgument(value)
+}
+
+// BindFragment bind values to the "?" markers of a raw sql fragment, the markers are rewritten in the
+// dialect's bind pattern. The number of markers and values must be equal, a fragment without values is
+// returned as written
+func (ctx *CompileContext) BindFragment(expression string, bindings ...interface{}) (string, error) {
+	return bindFragment(expression, bindings, ctx.cmpl.result.args)
+}
+
+// Depth return how many queries enclose the one being compiled, it is 0 for the statement itself
+func (ctx *CompileContext) Depth() int {
+	return len(ctx.cmpl.frames) - 1
+}
+
+// Dialect return the type of database the statement is compiled for
+func (ctx *CompileContext) Dialect() databaseType {
+	return ctx.cmpl.engine
+}
+
+// Subquery compile q as a nested query of the statement and return its sql without parentheses, arguments of
+// q are bound to the statement
+func (ctx *CompileContext) Subquery(q *Query) (string, error) {
+	return ctx.cmpl.compileSubquery(q)
+}
+
+// ConditionCompiler compiles the built-in conditions of WHERE and HAVING clauses. Every dialect has one, and
+// Builder.SetConditionCompiler replaces it. To change a few conditions only, embed the builder's current
+// ConditionCompiler in a struct and override its methods
+type ConditionCompiler interface {
+	CompileCompare(ctx *CompileContext, column, sign string, value interface{}, not bool) (string, error)
+	CompileColumnCompare(ctx *CompileContext, left, sign, right string, not bool) (string, error)
+	CompileLike(ctx *CompileContext, column, pattern string, not bool) (string, error)
+	CompileBetween(ctx *CompileContext, column string, from, to interface{}, not bool) (string, error)
+	CompileIn(ctx *CompileContext, column string, members []interface{}, not bool) (string, error)
+	CompileInQuery(ctx *CompileContext, column string, subQuery *Query, not bool) (string, error)
+	CompileNull(ctx *CompileContext, column string, not bool) (string, error)
+	CompileBoolean(ctx *CompileContext, column string, value bool, not bool) (string, error)
+	CompileExists(ctx *CompileContext, subQuery *Query, not bool) (string, error)
+	CompileRaw(ctx *CompileContext, expression string, bindings []interface{}, not bool) (string, error)
+}
+
+// Condition is a predicate defined outside the package, attach it to a query with WhereCond and OrWhereCond.
+// CompileCondition return the sql of the predicate, it should quote identifiers and bind values through ctx.
+// The sql is written in parentheses, and negated by Not()
+type Condition interface {
+	CompileCondition(ctx *CompileContext) (string, error)
+}
+
+// condition is an element of WHERE and HAVING clauses
+type condition interface {
+	element
+	isAndOperator() bool
+	accept(ctx *CompileContext, cc ConditionCompiler) (string, error)
+}
+
+func (c compareCondition) accept(ctx *CompileContext, cc ConditionCompiler) (string, error) {
+	return cc.CompileCompare(ctx, c.columnName, c.sign, c.value, c.isNot)
+}
+
+func (c columnCompareCondition) accept(ctx *CompileContext, cc ConditionCompiler) (string, error) {
+	return cc.CompileColumnCompare(ctx, c.leftColumn, c.sign, c.rightColumn, c.isNot)
+}
+
+func (c likeCondition) accept(ctx *CompileContext, cc ConditionCompiler) (string, error) {
+	return cc.CompileLike(ctx, c.columnName, c.like, c.isNot)
+}
+
+func (c betweenCondition) accept(ctx *CompileContext, cc ConditionCompiler) (string, error) {
+	return cc.CompileBetween(ctx, c.columnName, c.from, c.to, c.isNot)
+}
+
+func (c inCondition) accept(ctx *CompileContext, cc ConditionCompiler) (string, error) {
+	return cc.CompileIn(ctx, c.columnName, c.members, c.isNot)
+}
+
+func (c inQueryCondition) accept(ctx *CompileContext, cc ConditionCompiler) (string, error) {
+	return cc.CompileInQuery(ctx, c.columnName, c.subQuery, c.isNot)
+}
+
+func (c nullCondition) accept(ctx *CompileContext, cc ConditionCompiler) (string, error) {
+	return cc.CompileNull(ctx, c.columnName, c.isNot)
+}
+
+func (c booleanCondition) accept(ctx *CompileContext, cc ConditionCompiler) (string, error) {
+	return cc.CompileBoolean(ctx, c.columnName, c.value, c.isNot)
+}
+
+func (c existsCondition) accept(ctx *CompileContext, cc ConditionCompiler) (string, error) {
+	return cc.CompileExists(ctx, c.subQuery, c.isNot)
+}
+
+func (c rawCodition) accept(ctx *CompileContext, cc ConditionCompiler) (string, error) {
+	return cc.CompileRaw(ctx, c.expression, c.bindings, c.isNot)
+}
+
+func (c customCondition) accept(ctx *CompileContext, cc ConditionCompiler) (string, error) {
+	s, err := c.cond.CompileCondition(ctx)
+	if err != nil {
+		return "", err
+	}
+	// parenthesized so an OR in s doesn't bind with the conditions around it
+	if c.isNot {
+		return kwNOT + " (" + s + ")", nil
+	}
+	return "(" + s + ")", nil
+}
+
+// standardConditions is the ConditionCompiler shared by all dialects
+type standardConditions struct{}
+
+func (standardConditions) CompileCompare(ctx *CompileContext, column, sign string, value interface{}, not bool) (string, error) {
+	// TODO: check sign
+	var ph string
+	if sub, ok := value.(*Query); ok {
+		s, err := ctx.Subquery(sub)
+		if err != nil {
+			return "", err
+		}
+		ph = "(" + s + ")"
+	} else {
+		ph = ctx.Bind(value)
+	}
+	// not is ignored, Not() never negated a comparison and changing it would change existing queries
+	stmt := []string{ctx.Quote(column), sign, ph}
+	return strings.Join(stmt, kwSPACE), nil
+}
+
+func (standardConditions) CompileColumnCompare(ctx *CompileContext, left, sign, right string, not bool) (string, error) {
+	// not is ignored like in CompileCompare
+	stmt := []string{ctx.Quote(left), sign, ctx.Quote(right)}
+	return strings.Join(stmt, kwSPACE), nil
+}
+
+func (standardConditions) CompileLike(ctx *CompileContext, column, pattern string, not bool) (string, error) {
+	ph := ctx.Bind(pattern)
+	if not {
+		stmt := []string{ctx.Quote(column), kwNOT, kwLIKE, ph}
+		return strings.Join(stmt, kwSPACE), nil
+	}
+	stmt := []string{ctx.Quote(column), kwLIKE, ph}
+	return strings.Join(stmt, kwSPACE), nil
+}
+
+func (standardConditions) CompileBetween(ctx *CompileContext, column string, from, to interface{}, not bool) (string, error) {
+	f := ctx.Bind(from)
+	t := ctx.Bind(to)
+	if not {
+		stmt := []string{ctx.Quote(column), kwNOT, kwBETWEEN, f, kwAND, t}
+		return strings.Join(stmt, kwSPACE), nil
+	}
+	stmt := []string{ctx.Quote(column), kwBETWEEN, f, kwAND, t}
+	return strings.Join(stmt, kwSPACE), nil
+}
+
+func (standardConditions) CompileIn(ctx *CompileContext, column string, members []interface{}, not bool) (string, error) {
+	var stmt []string
+	if not {
+		stmt = []string{ctx.Quote(column), kwNOT, kwIN, "("}
+	} else {
+		stmt = []string{ctx.Quote(column), kwIN, "("}
+	}
+	smbr := make([]string, 0, len(members))
+	for _, mbr := range members {
+		smbr = append(smbr, ctx.Bind(mbr))
+	}
+	stmt = append(stmt, strings.Join(smbr, kwCOMMA), ")")
+	return strings.Join(stmt, kwSPACE), nil
+}
+
+func (standardConditions) CompileInQuery(ctx *CompileContext, column string, subQuery *Query, not bool) (string, error) {
+	stmt := make([]string, 0, 4)
+	stmt = append(stmt, ctx.Quote(column))
+	sub, err := ctx.Subquery(subQuery)
+	if err != nil {
+		return "", err
+	}
+	subq := "(" + sub + ")"
+	if not {
+		stmt = append(stmt, kwNOT, kwIN, subq)
+	} else {
+		stmt = append(stmt, kwIN, subq)
+	}
+	return strings.Join(stmt, kwSPACE), nil
+}
+
+func (standardConditions) CompileNull(ctx *CompileContext, column string, not bool) (string, error) {
+	if not {
+		stmt := []string{ctx.Quote(column), kwIS, kwNOT, kwNULL}
+		return strings.Join(stmt, kwSPACE), nil
+	}
+	stmt := []string{ctx.Quote(column), kwIS, kwNULL}
+	return strings.Join(stmt, kwSPACE), nil
+}
+
+func (standardConditions) CompileBoolean(ctx *CompileContext, column string, value bool, not bool) (string, error) {
+	if value == not {
+		stmt := []string{ctx.Quote(column), "=", kwFALSE}
+		return strings.Join(stmt, kwSPACE), nil
+	}
+	stmt := []string{ctx.Quote(column), "=", kwTRUE}
+	return strings.Join(stmt, kwSPACE), nil
+}
+
+func (standardConditions) CompileExists(ctx *CompileContext, subQuery *Query, not bool) (string, error) {
+	sub, err := ctx.Subquery(subQuery)
+	if err != nil {
+		return "", err
+	}
+	if not {
+		return kwNOT + kwSPACE + kwEXISTS + " (" + sub + ")", nil
+	}
+	return kwEXISTS + " (" + sub + ")", nil
+}
+
+func (standardConditions) CompileRaw(ctx *CompileContext, expression string, bindings []interface{}, not bool) (string, error) {
+	// raw conditions ignore not as they always did, write NOT in the expression
+	expression, err := ctx.BindFragment(expression, bindings...)
+	if err != nil {
+		return "", &CompileError{"compileConditions", err}
+	}
+	return expression, nil
+}
@@ -8,6 +8,9 @@ type bindPattern int
 type databaseType int
 type joinType int
 type queryMethod int
+type writeJoin int
+type groupingKind int
+type columnKind int
 
 // Type of sql bind parameters
 const (
@@ -26,13 +29,42 @@ const (
 	Standard
 )
 
+func (d databaseType) String() string {
+	switch d {
+	case SQLite:
+		return "SQLite"
+	case MySQL:
+		return "MySQL"
+	case PostgreSQL:
+		return "PostgreSQL"
+	default:
+		return "Standard"
+	}
+}
+
 const (
 	selectMethod queryMethod = iota
 	insertMethod
 	updateMethod
 	deleteMethod
+	truncateMethod
 )
 
+func (m queryMethod) String() string {
+	switch m {
+	case insertMethod:
+		return "insert"
+	case updateMethod:
+		return "update"
+	case deleteMethod:
+		return "delete"
+	case truncateMethod:
+		return "truncate"
+	default:
+		return "select"
+	}
+}
+
 const (
 	leftJoin joinType = iota
 	rightJoin
@@ -40,45 +72,111 @@ const (
 	fullJoin
 )
 
+// Kind of GROUP BY clause
+const (
+	plainGrouping groupingKind = iota
+	rollupGrouping
+	cubeGrouping
+	setsGrouping
+)
+
+// Type of column of DDL statements
+const (
+	integerColumn columnKind = iota
+	bigIntegerColumn
+	smallIntegerColumn
+	stringColumn
+	textColumn
+	booleanColumn
+	floatColumn
+	decimalColumn
+	dateColumn
+	dateTimeColumn
+	jsonColumn
+	binaryColumn
+	uuidColumn
+)
+
+// How the joins of an UPDATE or DELETE statement are written
+const (
+	noWriteJoin       writeJoin = iota
+	inlineWriteJoin             // UPDATE a JOIN b ... SET, DELETE a FROM a JOIN b
+	fromWriteJoin               // UPDATE a SET ... FROM b, DELETE FROM a USING b
+	subqueryWriteJoin           // WHERE id IN (SELECT id FROM a JOIN b ...)
+)
+
 // Sql keywords
 const (
-	kwSELECT    string = "SELECT"
-	kwUPDATE    string = "UPDATE"
-	kwDELETE    string = "DELETE FROM"
-	kwINSERT    string = "INSERT INTO"
-	kwFROM      string = "FROM"
-	kwJOIN      string = "JOIN"
-	kwWHERE     string = "WHERE"
-	kwSET       string = "SET"
-	kwNOT       string = "NOT"
-	kwLIKE      string = "LIKE"
-	kwBETWEEN   string = "BETWEEN"
-	kwIN        string = "IN"
-	kwON        string = "ON"
-	kwLIMIT     string = "LIMIT"
-	kwOFFSET    string = "OFFSET"
-	kwFETCH     string = "FETCH NEXT"
-	kwORDERBY   string = "ORDER BY"
-	kwGROUPBY   string = "GROUP BY"
-	kwROWS      string = "ROWS"
-	kwONLY      string = "ONLY"
-	kwDESC      string = "DESC"
-	kwASC       string = "ASC"
-	kwDISTINCT  string = "DISTINCT"
-	kwVALUES    string = "VALUES"
-	kwIS        string = "IS"
-	kwNULL      string = "NULL"
-	kwAND       string = "AND"
-	kwOR        string = "OR"
-	kwAS        string = " AS "
-	kwSPACE     string = " "
-	kwALL       string = "*"
-	kwCOMMA     string = ", "
-	kwFALSE     string = "False"
-	kwTRUE      string = "True"
-	kwHAVING    string = "HAVING"
-	kwLEFTJOIN  string = "LEFT JOIN"
-	kwRIGHTJOIN string = "RIGHT JOIN"
-	kwINNERJOIN string = "INNER JOIN"
-	kwEXISTS    string = "EXISTS"
+	kwSELECT     string = "SELECT"
+	kwUPDATE     string = "UPDATE"
+	kwDELETE     string = "DELETE FROM"
+	kwDELETEJOIN string = "DELETE"
+	kwINSERT     string = "INSERT INTO"
+	kwFROM       string = "FROM"
+	kwJOIN       string = "JOIN"
+	kwWHERE      string = "WHERE"
+	kwSET        string = "SET"
+	kwNOT        string = "NOT"
+	kwLIKE       string = "LIKE"
+	kwBETWEEN    string = "BETWEEN"
+	kwIN         string = "IN"
+	kwON         string = "ON"
+	kwLIMIT      string = "LIMIT"
+	kwOFFSET     string = "OFFSET"
+	kwFORUPDATE  string = "FOR UPDATE"
+	kwFETCH      string = "FETCH NEXT"
+	kwORDERBY    string = "ORDER BY"
+	kwGROUPBY    string = "GROUP BY"
+	kwROWS       string = "ROWS"
+	kwONLY       string = "ONLY"
+	kwDESC       string = "DESC"
+	kwASC        string = "ASC"
+	kwNULLSFIRST string = "NULLS FIRST"
+	kwNULLSLAST  string = "NULLS LAST"
+	kwROLLUP     string = "ROLLUP"
+	kwCUBE       string = "CUBE"
+	kwSETS       string = "GROUPING SETS"
+	kwWITHROLLUP string = "WITH ROLLUP"
+	kwDISTINCT   string = "DISTINCT"
+	kwVALUES     string = "VALUES"
+	kwIS         string = "IS"
+	kwNULL       string = "NULL"
+	kwAND        string = "AND"
+	kwOR         string = "OR"
+	kwAS         string = " AS "
+	kwSPACE      string = " "
+	kwALL        string = "*"
+	kwCOMMA      string = ", "
+	kwFALSE      string = "False"
+	kwTRUE       string = "True"
+	kwHAVING     string = "HAVING"
+	kwLEFTJOIN   string = "LEFT JOIN"
+	kwRIGHTJOIN  string = "RIGHT JOIN"
+	kwINNERJOIN  string = "INNER JOIN"
+	kwEXISTS     string = "EXISTS"
+	kwUSING      string = "USING"
+	kwTRUNCATE   string = "TRUNCATE TABLE"
+)
+
+// keywords of DDL statements
+const (
+	kwCREATETABLE   string = "CREATE TABLE"
+	kwALTERTABLE    string = "ALTER TABLE"
+	kwDROPTABLE     string = "DROP TABLE"
+	kwIFNOTEXISTS   string = "IF NOT EXISTS"
+	kwIFEXISTS      string = "IF EXISTS"
+	kwADDCOLUMN     string = "ADD COLUMN"
+	kwDROPCOLUMN    string = "DROP COLUMN"
+	kwRENAMECOLUMN  string = "RENAME COLUMN"
+	kwRENAMETO      string = "RENAME TO"
+	kwCREATEINDEX   string = "CREATE INDEX"
+	kwCREATEUNIQUE  string = "CREATE UNIQUE INDEX"
+	kwDROPINDEX     string = "DROP INDEX"
+	kwPRIMARYKEY    string = "PRIMARY KEY"
+	kwFOREIGNKEY    string = "FOREIGN KEY"
+	kwREFERENCES    string = "REFERENCES"
+	kwCONSTRAINT    string = "CONSTRAINT"
+	kwUNIQUE        string = "UNIQUE"
+	kwDEFAULT       string = "DEFAULT"
+	kwAUTOINCREMENT string = "AUTOINCREMENT"
 )
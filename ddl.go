@@ -0,0 +1,288 @@
+package gqbuilder
+
+/*
+	compile DDL statements defined by Schema
+*/
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func (c *baseCompiler) compileCreateTable(t *TableDef, ifNotExists bool) ([]string, error) {
+	if len(t.columns) == 0 {
+		return nil, &CompileError{"compileCreateTable", errors.New("table " + t.name + " has no columns")}
+	}
+	defs := make([]string, 0, len(t.columns)+len(t.foreigns)+1)
+	for _, col := range t.columns {
+		def, err := c.compileColumnDef(col)
+		if err != nil {
+			return nil, &CompileError{"compileCreateTable", err}
+		}
+		defs = append(defs, def)
+	}
+	if len(t.primary) > 0 {
+		defs = append(defs, kwPRIMARYKEY+" ("+strings.Join(c.wrapWords(t.primary), kwCOMMA)+")")
+	}
+	for _, fk := range t.foreigns {
+		def, err := c.compileForeignKey(fk)
+		if err != nil {
+			return nil, &CompileError{"compileCreateTable", err}
+		}
+		defs = append(defs, def)
+	}
+
+	create := kwCREATETABLE + kwSPACE
+	if ifNotExists {
+		create += kwIFNOTEXISTS + kwSPACE
+	}
+	stmts := []string{create + c.wrapWord(t.name) + " (" + strings.Join(defs, kwCOMMA) + ")"}
+	for _, idx := range t.indexes {
+		stmts = append(stmts, c.compileCreateIndex(t.name, idx))
+	}
+	return stmts, nil
+}
+
+func (c *baseCompiler) compileAlterTable(t *TableDef) ([]string, error) {
+	alter := kwALTERTABLE + kwSPACE + c.wrapWord(t.name) + kwSPACE
+	var stmts []string
+	for _, col := range t.columns {
+		def, err := c.compileColumnDef(col)
+		if err != nil {
+			return nil, &CompileError{"compileAlterTable", err}
+		}
+		stmts = append(stmts, alter+kwADDCOLUMN+kwSPACE+def)
+	}
+	for _, ren := range t.renames {
+		stmts = append(stmts, alter+kwRENAMECOLUMN+kwSPACE+c.wrapWord(ren[0])+" TO "+c.wrapWord(ren[1]))
+	}
+	for _, name := range t.dropIdx {
+		stmts = append(stmts, c.compileDropIndex(t.name, name))
+	}
+	for _, name := range t.dropFks {
+		switch c.engine {
+		case MySQL:
+			stmts = append(stmts, alter+"DROP "+kwFOREIGNKEY+kwSPACE+c.wrapWord(name))
+		case SQLite:
+			return nil, &CompileError{"compileAlterTable", &UnsupportedError{c.engine, "ALTER TABLE DROP FOREIGN KEY"}}
+		default:
+			stmts = append(stmts, alter+"DROP "+kwCONSTRAINT+kwSPACE+c.wrapWord(name))
+		}
+	}
+	for _, name := range t.drops {
+		stmts = append(stmts, alter+kwDROPCOLUMN+kwSPACE+c.wrapWord(name))
+	}
+	if len(t.primary) > 0 {
+		if c.engine == SQLite {
+			return nil, &CompileError{"compileAlterTable", &UnsupportedError{c.engine, "ALTER TABLE ADD PRIMARY KEY"}}
+		}
+		stmts = append(stmts, alter+"ADD "+kwPRIMARYKEY+" ("+strings.Join(c.wrapWords(t.primary), kwCOMMA)+")")
+	}
+	for _, fk := range t.foreigns {
+		if c.engine == SQLite {
+			return nil, &CompileError{"compileAlterTable", &UnsupportedError{c.engine, "ALTER TABLE ADD FOREIGN KEY"}}
+		}
+		def, err := c.compileForeignKey(fk)
+		if err != nil {
+			return nil, &CompileError{"compileAlterTable", err}
+		}
+		stmts = append(stmts, alter+"ADD "+def)
+	}
+	for _, idx := range t.indexes {
+		stmts = append(stmts, c.compileCreateIndex(t.name, idx))
+	}
+	return stmts, nil
+}
+
+// compileColumnDef return the definition of a column, auto-increment columns are SERIAL on PostgreSQL,
+// AUTO_INCREMENT on MySQL, INTEGER PRIMARY KEY AUTOINCREMENT on SQLite and identity columns elsewhere
+func (c *baseCompiler) compileColumnDef(col *ColumnDef) (string, error) {
+	def := []string{c.wrapWord(col.name), c.columnType(col)}
+	if col.unsigned && c.engine == MySQL && isIntegerColumn(col.kind) {
+		def = append(def, "UNSIGNED")
+	}
+	if col.autoIncrement {
+		switch c.engine {
+		case MySQL:
+			def = append(def, kwNOT+kwSPACE+kwNULL, "AUTO_INCREMENT")
+		case SQLite:
+			if !col.primary {
+				return "", errors.New("auto-increment column " + col.name + " must be the primary key on SQLite")
+			}
+		case Standard:
+			def = append(def, "GENERATED BY DEFAULT AS IDENTITY")
+		}
+	}
+	if col.notNull && !(col.autoIncrement && c.engine == MySQL) {
+		def = append(def, kwNOT+kwSPACE+kwNULL)
+	}
+	if col.hasDefault {
+		if col.defaultRaw != "" {
+			def = append(def, kwDEFAULT, col.defaultRaw)
+		} else if col.defaultValue == nil {
+			def = append(def, kwDEFAULT, kwNULL)
+		} else {
+			lit, err := c.defaultLiteral(col)
+			if err != nil {
+				return "", err
+			}
+			def = append(def, kwDEFAULT, lit)
+		}
+	}
+	if col.unique {
+		def = append(def, kwUNIQUE)
+	}
+	if col.primary {
+		def = append(def, kwPRIMARYKEY)
+		if col.autoIncrement && c.engine == SQLite {
+			def = append(def, kwAUTOINCREMENT)
+		}
+	}
+	return strings.Join(def, kwSPACE), nil
+}
+
+// defaultLiteral return the literal of the default value of col. Times are written as quoted local dates and
+// times, which every dialect casts to DATE, DATETIME and TIMESTAMP columns
+func (c *baseCompiler) defaultLiteral(col *ColumnDef) (string, error) {
+	v, ok := col.defaultValue.(time.Time)
+	if !ok {
+		return new(SQLResult).literal(col.defaultValue)
+	}
+	switch col.kind {
+	case dateColumn:
+		return "'" + v.Format("2006-01-02") + "'", nil
+	case dateTimeColumn, stringColumn, textColumn:
+		return "'" + v.Format("2006-01-02 15:04:05.999999") + "'", nil
+	}
+	return "", errors.New("time default of column " + col.name + " which is not a date or time column")
+}
+
+func isIntegerColumn(kind columnKind) bool {
+	return kind == integerColumn || kind == bigIntegerColumn || kind == smallIntegerColumn
+}
+
+// columnType return the dialect's type of a column
+func (c *baseCompiler) columnType(col *ColumnDef) string {
+	if col.autoIncrement {
+		switch c.engine {
+		case PostgreSQL:
+			switch col.kind {
+			case bigIntegerColumn:
+				return "BIGSERIAL"
+			case smallIntegerColumn:
+				return "SMALLSERIAL"
+			default:
+				return "SERIAL"
+			}
+		case SQLite:
+			// only INTEGER PRIMARY KEY is an alias of the rowid
+			return "INTEGER"
+		}
+	}
+	switch col.kind {
+	case integerColumn:
+		if c.engine == MySQL {
+			return "INT"
+		}
+		return "INTEGER"
+	case bigIntegerColumn:
+		if c.engine == SQLite {
+			return "INTEGER"
+		}
+		return "BIGINT"
+	case smallIntegerColumn:
+		if c.engine == SQLite {
+			return "INTEGER"
+		}
+		return "SMALLINT"
+	case stringColumn:
+		return "VARCHAR(" + strconv.Itoa(col.length) + ")"
+	case textColumn:
+		return "TEXT"
+	case booleanColumn:
+		if c.engine == MySQL {
+			return "TINYINT(1)"
+		}
+		return "BOOLEAN"
+	case floatColumn:
+		switch c.engine {
+		case MySQL:
+			return "DOUBLE"
+		case SQLite:
+			return "REAL"
+		default:
+			return "DOUBLE PRECISION"
+		}
+	case decimalColumn:
+		if c.engine == SQLite {
+			return "NUMERIC"
+		}
+		return "DECIMAL(" + strconv.Itoa(col.precision) + kwCOMMA + strconv.Itoa(col.scale) + ")"
+	case dateColumn:
+		return "DATE"
+	case dateTimeColumn:
+		if c.engine == PostgreSQL || c.engine == Standard {
+			return "TIMESTAMP"
+		}
+		return "DATETIME"
+	case jsonColumn:
+		switch c.engine {
+		case PostgreSQL:
+			return "JSONB"
+		case SQLite:
+			return "TEXT"
+		default:
+			return "JSON"
+		}
+	case binaryColumn:
+		if c.engine == PostgreSQL {
+			return "BYTEA"
+		}
+		return "BLOB"
+	case uuidColumn:
+		switch c.engine {
+		case MySQL:
+			return "CHAR(36)"
+		case SQLite:
+			return "TEXT"
+		default:
+			return "UUID"
+		}
+	}
+	return "TEXT"
+}
+
+func (c *baseCompiler) compileForeignKey(fk *ForeignKeyDef) (string, error) {
+	if fk.refTable == "" || len(fk.refColumns) != len(fk.columns) {
+		return "", errors.New("foreign key " + fk.name + " must reference as many columns as it has")
+	}
+	def := kwCONSTRAINT + kwSPACE + c.wrapWord(fk.name) + kwSPACE +
+		kwFOREIGNKEY + " (" + strings.Join(c.wrapWords(fk.columns), kwCOMMA) + ") " +
+		kwREFERENCES + kwSPACE + c.wrapWord(fk.refTable) + " (" + strings.Join(c.wrapWords(fk.refColumns), kwCOMMA) + ")"
+	if fk.onDelete != "" {
+		def += " ON DELETE " + strings.ToUpper(fk.onDelete)
+	}
+	if fk.onUpdate != "" {
+		def += " ON UPDATE " + strings.ToUpper(fk.onUpdate)
+	}
+	return def, nil
+}
+
+func (c *baseCompiler) compileCreateIndex(table string, idx indexDef) string {
+	create := kwCREATEINDEX
+	if idx.unique {
+		create = kwCREATEUNIQUE
+	}
+	return create + kwSPACE + c.wrapWord(idx.name) + kwSPACE + kwON + kwSPACE + c.wrapWord(table) +
+		" (" + strings.Join(c.wrapWords(idx.columns), kwCOMMA) + ")"
+}
+
+// compileDropIndex return DROP INDEX, MySQL names the table of the index
+func (c *baseCompiler) compileDropIndex(table, name string) string {
+	if c.engine == MySQL {
+		return kwDROPINDEX + kwSPACE + c.wrapWord(name) + kwSPACE + kwON + kwSPACE + c.wrapWord(table)
+	}
+	return kwDROPINDEX + kwSPACE + c.wrapWord(name)
+}
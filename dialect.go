@@ -0,0 +1,51 @@
+package gqbuilder
+
+/*
+	compile a query for other dialects than the one of its builder
+*/
+
+import (
+	"errors"
+)
+
+// dialects known to compilerFactory, in the order of CompileAll
+var dialects = []databaseType{SQLite, MySQL, PostgreSQL, Standard}
+
+// Dialects return the dialects a query can be compiled for
+func Dialects() []databaseType {
+	return append([]databaseType(nil), dialects...)
+}
+
+// DialectSQL is a query compiled for one dialect. Err matches ErrUnsupported when the dialect can't express
+// the query, Result is nil then
+type DialectSQL struct {
+	Dialect databaseType
+	Result  *SQLResult
+	Err     error
+}
+
+// CompileFor compile the query for dialect, with the quoting and bind variables of that dialect and the
+// condition compiler of the builder. The builder and its database are left alone, the bind pattern set by
+// Builder.WithBindPattern isn't applied
+func (q *Query) CompileFor(dialect databaseType) (*SQLResult, error) {
+	cmpl := compilerFactory(dialect)
+	cmpl.setConditionCompiler(q.builder.cmpl.conditionCompiler())
+	return cmpl.compile(q)
+}
+
+// CompileAll compile the query for every dialect of Dialects. A dialect which can't express the query, e.g.
+// GROUP BY CUBE on MySQL, is flagged by the Err of its DialectSQL; other errors fail the whole call
+func (q *Query) CompileAll() ([]DialectSQL, error) {
+	all := make([]DialectSQL, 0, len(dialects))
+	for _, d := range dialects {
+		rst, err := q.CompileFor(d)
+		if err != nil && !errors.Is(err, ErrUnsupported) {
+			return nil, err
+		}
+		if err != nil {
+			rst = nil
+		}
+		all = append(all, DialectSQL{Dialect: d, Result: rst, Err: err})
+	}
+	return all, nil
+}
@@ -0,0 +1,60 @@
+package gqbuilder
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func TestCompileFor(t *testing.T) {
+	var con *sql.DB
+	bdr := NewBuilder(SQLite, con)
+	q := bdr.Query("user").Select("id", "name").Where("age", ">", 20).OrderBy("name").Limit(5)
+	expect := map[databaseType]string{
+		SQLite:     `SELECT "id", "name" FROM "user" WHERE "age" > ? ORDER BY "name" ASC LIMIT 5`,
+		MySQL:      "SELECT `id`, `name` FROM `user` WHERE `age` > ? ORDER BY `name` ASC LIMIT 5",
+		PostgreSQL: `SELECT "id", "name" FROM "user" WHERE "age" > $1 ORDER BY "name" ASC LIMIT 5`,
+	}
+	for d, want := range expect {
+		rst, err := q.CompileFor(d)
+		if err != nil {
+			t.Errorf("test compile for %s error: %s\n", d, err)
+			continue
+		}
+		if raw, args := rst.ToPrepared(); raw != want || len(args) != 1 || args[0] != 20 {
+			t.Errorf("test compile for %s: expect %s, got %s %v\n", d, want, raw, args)
+		}
+	}
+	if bdr.Dialect() != SQLite {
+		t.Errorf("test compile for: builder dialect changed to %s\n", bdr.Dialect())
+	}
+	if raw, _, _ := q.ToPrepared(); raw != expect[SQLite] {
+		t.Errorf("test compile for: builder compiles %s\n", raw)
+	}
+}
+
+func TestCompileAll(t *testing.T) {
+	var con *sql.DB
+	bdr := NewBuilder(PostgreSQL, con)
+	q := bdr.Query("sales").Select("region", "product").GroupByCube("region", "product")
+	all, err := q.CompileAll()
+	if err != nil {
+		t.Errorf("test compile all error: %s\n", err)
+		return
+	}
+	if len(all) != len(Dialects()) {
+		t.Errorf("test compile all: expect %d dialects, got %d\n", len(Dialects()), len(all))
+		return
+	}
+	for _, ds := range all {
+		unsupported := ds.Dialect == SQLite || ds.Dialect == MySQL
+		if unsupported != errors.Is(ds.Err, ErrUnsupported) || unsupported != (ds.Result == nil) {
+			t.Errorf("test compile all: %s unexpected %v %v\n", ds.Dialect, ds.Result, ds.Err)
+		}
+	}
+
+	_, err = bdr.Query("user").Delete().CompileAll()
+	if !errors.Is(err, ErrUnsafeWrite) {
+		t.Errorf("test compile all: expect ErrUnsafeWrite, got %v\n", err)
+	}
+}
@@ -0,0 +1,98 @@
+package gqbuilder
+
+/*
+	hooks observe the statements executed by a builder
+*/
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// operations of QueryEvent
+const (
+	opQuery    = "query"
+	opQueryRow = "query_row"
+	opExec     = "exec"
+)
+
+// QueryEvent describes a statement executed by a builder. Hooks share the event of a statement, a change made
+// by one hook, e.g. to Args, is seen by the hooks after it but never by the database
+type QueryEvent struct {
+	// Operation is "query", "query_row" or "exec"
+	Operation string
+	// Method is "select", "insert", "update", "delete" or "truncate" for queries, "ddl" for schema statements
+	Method  string
+	Table   string
+	Dialect databaseType
+	SQL     string
+	Args    []interface{}
+	Start   time.Time
+	// Duration is set before After is called. For "query" it ends when the rows are returned, not read
+	Duration time.Duration
+	// RowsAffected is -1 when the operation doesn't report it
+	RowsAffected int64
+	// Err is the error of the execution, errors of Row.Scan and Rows.Next aren't known to hooks
+	Err error
+}
+
+// stmtInfo is what hooks know of a statement besides its sql
+type stmtInfo struct {
+	method  string
+	table   string
+	primary bool // a read which must not run on a replica
+}
+
+// Hook observes the statements executed by Query.Do, Get, Exec, PreparedQuery and SchemaStatement.Exec.
+// Before is called in registration order and the context it returns is used for the statement, After is
+// called in reverse order with the context its Before returned
+type Hook interface {
+	Before(ctx context.Context, ev *QueryEvent) context.Context
+	After(ctx context.Context, ev *QueryEvent)
+}
+
+// AddHook register hooks on the builder. Register them before the builder is used by other goroutines
+func (b *Builder) AddHook(hooks ...Hook) *Builder {
+	b.hooks = append(b.hooks, hooks...)
+	return b
+}
+
+// observe run fn, which return the rows affected, between the hooks of the builder
+func (b *Builder) observe(ctx context.Context, op string, info stmtInfo, query string, args []interface{}, fn func(ctx context.Context) (int64, error)) error {
+	if len(b.hooks) == 0 {
+		_, err := fn(ctx)
+		return err
+	}
+	ev := &QueryEvent{
+		Operation: op,
+		Method:    info.method,
+		Table:     info.table,
+		Dialect:   b.driver,
+		SQL:       query,
+		Args:      append([]interface{}(nil), args...),
+	}
+	ctxs := make([]context.Context, len(b.hooks))
+	for i, h := range b.hooks {
+		ctx = h.Before(ctx, ev)
+		ctxs[i] = ctx
+	}
+	ev.Start = time.Now()
+	ev.RowsAffected, ev.Err = fn(ctx)
+	ev.Duration = time.Since(ev.Start)
+	for i := len(b.hooks) - 1; i >= 0; i-- {
+		b.hooks[i].After(ctxs[i], ev)
+	}
+	return ev.Err
+}
+
+func rowsAffected(res sql.Result, err error) int64 {
+	if err != nil || res == nil {
+		return -1
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return -1
+	}
+	return n
+}
@@ -0,0 +1,139 @@
+package gqbuilder
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/paulnjiang/gqbuilder/internal/fakedb"
+)
+
+// recordLogger is a StructuredLogger keeping its messages
+type recordLogger struct {
+	lines []string
+}
+
+func (l *recordLogger) log(level, msg string, args ...interface{}) {
+	l.lines = append(l.lines, level+" "+msg+" "+fmt.Sprint(args...))
+}
+
+func (l *recordLogger) DebugContext(ctx context.Context, msg string, args ...interface{}) {
+	l.log("DEBUG", msg, args...)
+}
+
+func (l *recordLogger) InfoContext(ctx context.Context, msg string, args ...interface{}) {
+	l.log("INFO", msg, args...)
+}
+
+func (l *recordLogger) WarnContext(ctx context.Context, msg string, args ...interface{}) {
+	l.log("WARN", msg, args...)
+}
+
+func (l *recordLogger) ErrorContext(ctx context.Context, msg string, args ...interface{}) {
+	l.log("ERROR", msg, args...)
+}
+
+type ctxKey string
+
+// orderHook records the order of its calls and the context it gets
+type orderHook struct {
+	name  string
+	calls *[]string
+}
+
+func (h orderHook) Before(ctx context.Context, ev *QueryEvent) context.Context {
+	*h.calls = append(*h.calls, "before "+h.name)
+	return context.WithValue(ctx, ctxKey(h.name), true)
+}
+
+func (h orderHook) After(ctx context.Context, ev *QueryEvent) {
+	*h.calls = append(*h.calls, fmt.Sprintf("after %s %v", h.name, ctx.Value(ctxKey(h.name))))
+}
+
+func TestHooks(t *testing.T) {
+	fake, con := fakedb.New("hooks")
+	fake.Affected = func(string) int64 { return 1 }
+	var calls []string
+	logger := new(recordLogger)
+	var slow []*QueryEvent
+	bdr := NewBuilder(PostgreSQL, con).AddHook(
+		orderHook{"a", &calls},
+		NewRedactHook("password"),
+		orderHook{"b", &calls},
+		NewLogHook(logger),
+		NewSlowQueryHook(0, logger).OnSlow(func(ctx context.Context, ev *QueryEvent) { slow = append(slow, ev) }),
+	)
+	q := bdr.Query("user").Where("id", "=", 7).Update(map[string]interface{}{"password": "s3cret", "name": "bob"})
+	if _, err := q.Exec(context.Background()); err != nil {
+		t.Errorf("test hooks error: %s\n", err)
+		return
+	}
+	expect := "[before a before b after b true after a true]"
+	if fmt.Sprint(calls) != expect {
+		t.Errorf("test hooks: unexpected order %v\n", calls)
+	}
+	if len(slow) != 1 || slow[0].RowsAffected != 1 || fmt.Sprint(slow[0].Args) != "[bob [REDACTED] 7]" {
+		t.Errorf("test hooks: unexpected event %+v\n", slow)
+	}
+	if len(logger.lines) != 2 || logger.lines[0][:5] != "WARN " || logger.lines[1][:6] != "DEBUG " {
+		t.Errorf("test hooks: unexpected log %q\n", logger.lines)
+	}
+	if _, args, _ := q.ToPrepared(); fmt.Sprint(args) != "[bob s3cret 7]" {
+		t.Errorf("test hooks: arguments of the query changed %v\n", args)
+	}
+
+	fake.SetDown(true)
+	logger.lines = nil
+	if _, err := bdr.Query("user").Select("id").Get(context.Background()); err == nil {
+		t.Errorf("test hooks: expect an error from a down database\n")
+	}
+	if len(logger.lines) != 2 || logger.lines[1][:12] != "ERROR query " {
+		t.Errorf("test hooks: unexpected log of a failure %q\n", logger.lines)
+	}
+}
+
+func TestArgColumns(t *testing.T) {
+	cols := argColumns("INSERT INTO `user` (`name`, `password`) VALUES (?, ?), (?, ?)")
+	if fmt.Sprint(cols) != "[name password name password]" {
+		t.Errorf("test arg columns: unexpected insert columns %v\n", cols)
+	}
+	cols = argColumns(`SELECT * FROM "user" WHERE "u"."password" = $1 AND age >= $2 AND LOWER(email) = $3`)
+	if fmt.Sprint(cols) != "[password age ]" {
+		t.Errorf("test arg columns: unexpected columns %q\n", cols)
+	}
+	cols = argColumns("SELECT * FROM `user` WHERE `id` IN (?, ?) AND `code` NOT IN (?) AND COALESCE(?, 1) = 1")
+	if fmt.Sprintf("%q", cols) != `["id" "id" "code" ""]` {
+		t.Errorf("test arg columns: unexpected in columns %q\n", cols)
+	}
+	cols = argColumns(`SELECT * FROM "user" WHERE "name" LIKE $1 AND email NOT ILIKE $2`)
+	if fmt.Sprint(cols) != "[name email]" {
+		t.Errorf("test arg columns: unexpected like columns %q\n", cols)
+	}
+	cols = argColumns(`SELECT * FROM "user" WHERE "age" BETWEEN $1 AND $2 AND salary NOT BETWEEN $3 AND $4 AND a = $5`)
+	if fmt.Sprint(cols) != "[age age salary salary a]" {
+		t.Errorf("test arg columns: unexpected between columns %q\n", cols)
+	}
+}
+
+func TestRedactHook(t *testing.T) {
+	var matched []interface{}
+	hook := NewRedactHook("email", "ssn").Match(func(column string, value interface{}) bool {
+		matched = append(matched, value)
+		return column == "token"
+	})
+	ev := &QueryEvent{
+		SQL:  "SELECT * FROM `user` WHERE `ssn` IN (?, ?) AND `email` LIKE ? AND `ssn` BETWEEN ? AND ? AND `age` > ?",
+		Args: []interface{}{"1", "2", "%@x.org", "3", "4", 20},
+	}
+	hook.Before(context.Background(), ev)
+	if fmt.Sprint(ev.Args) != "[[REDACTED] [REDACTED] [REDACTED] [REDACTED] [REDACTED] 20]" {
+		t.Errorf("test redact hook: unexpected args %v\n", ev.Args)
+	}
+
+	matched = nil
+	ev = &QueryEvent{SQL: "SELECT * FROM `user` WHERE `token` = :token", Args: []interface{}{NamedParam{"token"}}}
+	hook.Before(context.Background(), ev)
+	if fmt.Sprint(ev.Args) != "[[REDACTED]]" || len(matched) == 0 || matched[len(matched)-1] != nil {
+		t.Errorf("test redact hook: unexpected named param %v, match got %v\n", ev.Args, matched)
+	}
+}
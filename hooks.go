@@ -0,0 +1,316 @@
+package gqbuilder
+
+/*
+	hooks bundled with the package: structured logging, slow queries and redaction of bound values
+*/
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// StructuredLogger is the part of a structured logger the bundled hooks write to, *slog.Logger satisfies it.
+// args are alternating keys and values
+type StructuredLogger interface {
+	DebugContext(ctx context.Context, msg string, args ...interface{})
+	InfoContext(ctx context.Context, msg string, args ...interface{})
+	WarnContext(ctx context.Context, msg string, args ...interface{})
+	ErrorContext(ctx context.Context, msg string, args ...interface{})
+}
+
+func eventAttrs(ev *QueryEvent) []interface{} {
+	attrs := []interface{}{
+		"op", ev.Operation,
+		"sql", ev.SQL,
+		"args", ev.Args,
+		"duration", ev.Duration,
+	}
+	if ev.RowsAffected >= 0 {
+		attrs = append(attrs, "rows", ev.RowsAffected)
+	}
+	if ev.Err != nil {
+		attrs = append(attrs, "err", ev.Err)
+	}
+	return attrs
+}
+
+// LogHook logs every statement at debug level and failed ones at error level
+type LogHook struct {
+	logger StructuredLogger
+}
+
+// NewLogHook return a hook logging to logger, register it after a RedactHook to log redacted values
+func NewLogHook(logger StructuredLogger) *LogHook {
+	return &LogHook{logger: logger}
+}
+
+// Before does nothing
+func (h *LogHook) Before(ctx context.Context, ev *QueryEvent) context.Context {
+	return ctx
+}
+
+// After log the statement
+func (h *LogHook) After(ctx context.Context, ev *QueryEvent) {
+	if ev.Err != nil {
+		h.logger.ErrorContext(ctx, "query failed", eventAttrs(ev)...)
+		return
+	}
+	h.logger.DebugContext(ctx, "query", eventAttrs(ev)...)
+}
+
+// SlowQueryHook reports the statements which take threshold or longer
+type SlowQueryHook struct {
+	threshold time.Duration
+	logger    StructuredLogger
+	onSlow    func(ctx context.Context, ev *QueryEvent)
+}
+
+// NewSlowQueryHook return a hook logging slow statements at warn level to logger, logger may be nil when
+// OnSlow is set
+func NewSlowQueryHook(threshold time.Duration, logger StructuredLogger) *SlowQueryHook {
+	return &SlowQueryHook{threshold: threshold, logger: logger}
+}
+
+// OnSlow call fn with every slow statement, besides logging it
+func (h *SlowQueryHook) OnSlow(fn func(ctx context.Context, ev *QueryEvent)) *SlowQueryHook {
+	h.onSlow = fn
+	return h
+}
+
+// Before does nothing
+func (h *SlowQueryHook) Before(ctx context.Context, ev *QueryEvent) context.Context {
+	return ctx
+}
+
+// After report the statement if it is slow
+func (h *SlowQueryHook) After(ctx context.Context, ev *QueryEvent) {
+	if ev.Duration < h.threshold {
+		return
+	}
+	if h.logger != nil {
+		h.logger.WarnContext(ctx, "slow query", append(eventAttrs(ev), "threshold", h.threshold)...)
+	}
+	if h.onSlow != nil {
+		h.onSlow(ctx, ev)
+	}
+}
+
+// Redacted replaces a sensitive value in QueryEvent.Args
+const Redacted = "[REDACTED]"
+
+// RedactHook replaces the sensitive values of QueryEvent.Args with Redacted for the hooks registered after
+// it, the database still gets the real values. A value is sensitive when it is bound to one of the columns,
+// e.g. `"password" = ?`, `email LIKE ?`, `id IN (?, ?)`, `age BETWEEN ? AND ?`, an INSERT column or a SET
+// column, or when it is a named argument of that name. Column names are compared case-insensitively.
+// The column is found by reading the sql backwards from the variable, a variable inside a function call or an
+// expression, e.g. `LOWER(email) = ?` or `password = CONCAT(?, salt)`, is bound to no column and only Match
+// can redact it
+type RedactHook struct {
+	columns map[string]bool
+	match   func(column string, value interface{}) bool
+}
+
+// NewRedactHook return a hook redacting the values of columns
+func NewRedactHook(columns ...string) *RedactHook {
+	h := &RedactHook{columns: make(map[string]bool, len(columns))}
+	for _, col := range columns {
+		h.columns[strings.ToLower(col)] = true
+	}
+	return h
+}
+
+// Match redact also the values for which fn return true, column is empty when it isn't known. value is nil
+// for a NamedParam, whose value isn't given yet
+func (h *RedactHook) Match(fn func(column string, value interface{}) bool) *RedactHook {
+	h.match = fn
+	return h
+}
+
+func (h *RedactHook) sensitive(column string, value interface{}) bool {
+	if column != "" && h.columns[strings.ToLower(column)] {
+		return true
+	}
+	return h.match != nil && h.match(column, value)
+}
+
+// Before redact the arguments of the event
+func (h *RedactHook) Before(ctx context.Context, ev *QueryEvent) context.Context {
+	cols := argColumns(ev.SQL)
+	seq := 0
+	for i, tok := range scanBindTokens(ev.SQL) {
+		switch tok.pattern {
+		case PlaceHolder:
+			if seq < len(ev.Args) && h.sensitive(cols[i], ev.Args[seq]) {
+				ev.Args[seq] = Redacted
+			}
+			seq++
+		case Ordinal:
+			if k := tok.index - 1; k >= 0 && k < len(ev.Args) && h.sensitive(cols[i], ev.Args[k]) {
+				ev.Args[k] = Redacted
+			}
+		case Naming:
+			for k, arg := range ev.Args {
+				if nam, ok := arg.(sql.NamedArg); ok && nam.Name == tok.name && h.sensitive(cols[i], nam.Value) {
+					ev.Args[k] = sql.Named(nam.Name, Redacted)
+				}
+			}
+		}
+	}
+	for i, arg := range ev.Args {
+		switch v := arg.(type) {
+		case sql.NamedArg:
+			if h.sensitive(v.Name, v.Value) {
+				ev.Args[i] = sql.Named(v.Name, Redacted)
+			}
+		case NamedParam:
+			if h.sensitive(v.Name, nil) {
+				ev.Args[i] = Redacted
+			}
+		}
+	}
+	return ctx
+}
+
+// After does nothing
+func (h *RedactHook) After(ctx context.Context, ev *QueryEvent) {}
+
+// argColumns guess the column of every bind variable of sqlText, "" when unknown. A variable is the value
+// of its INSERT column, or of the column before it found by columnBefore
+func argColumns(sqlText string) []string {
+	toks := scanBindTokens(sqlText)
+	cols := make([]string, len(toks))
+	insertCols, valuesAt := insertColumns(sqlText)
+	n := 0
+	for i, tok := range toks {
+		if len(insertCols) > 0 && tok.start > valuesAt {
+			cols[i] = insertCols[n%len(insertCols)]
+			n++
+			continue
+		}
+		cols[i] = columnBefore(sqlText, tok.start)
+	}
+	return cols
+}
+
+// columnBefore return the column a bind variable starting at pos is compared to or assigned to, e.g.
+// `"u"."password" =`, `email LIKE`, `id IN (?, ` or `age BETWEEN ? AND`. It return "" for other places,
+// e.g. a function argument or an expression on the left of the operator
+func columnBefore(sqlText string, pos int) string {
+	j := spaceBefore(sqlText, pos-1)
+	if j < 0 {
+		return ""
+	}
+	if strings.IndexByte("=<>!", sqlText[j]) >= 0 {
+		for j >= 0 && strings.IndexByte("=<>!", sqlText[j]) >= 0 {
+			j--
+		}
+		return nameBefore(sqlText, j)
+	}
+	if sqlText[j] == '(' || sqlText[j] == ',' {
+		// a value of an IN list holding only bind variables
+		for j >= 0 && sqlText[j] != '(' {
+			if !isBindByte(sqlText[j]) && sqlText[j] != ',' && sqlText[j] != ' ' {
+				return ""
+			}
+			j--
+		}
+		word, k := wordBefore(sqlText, j-1)
+		if word != kwIN {
+			return ""
+		}
+		return nameBefore(sqlText, notBefore(sqlText, k))
+	}
+	word, k := wordBefore(sqlText, j)
+	if word == kwAND {
+		// the upper bound of BETWEEN, after the lower bound variable
+		if bound, b := wordBefore(sqlText, k); bound == "" || !isBindByte(bound[0]) {
+			return ""
+		} else if word, k = wordBefore(sqlText, b); word != kwBETWEEN {
+			return ""
+		}
+	}
+	switch word {
+	case kwLIKE, "ILIKE", kwBETWEEN:
+		return nameBefore(sqlText, notBefore(sqlText, k))
+	}
+	return ""
+}
+
+// spaceBefore return the position of the last byte before j+1 which isn't a space, -1 when there is none
+func spaceBefore(sqlText string, j int) int {
+	for j >= 0 && (sqlText[j] == ' ' || sqlText[j] == '\n' || sqlText[j] == '\t') {
+		j--
+	}
+	return j
+}
+
+func isBindByte(ch byte) bool {
+	return isNameByte(ch) || ch == '?' || ch == '$' || ch == ':' || ch == '@'
+}
+
+// wordBefore return the upper cased word or bind variable ending at j after spaces, and the position before it
+func wordBefore(sqlText string, j int) (string, int) {
+	j = spaceBefore(sqlText, j)
+	end := j + 1
+	for j >= 0 && isBindByte(sqlText[j]) {
+		j--
+	}
+	return strings.ToUpper(sqlText[j+1 : end]), j
+}
+
+// notBefore skip the NOT of "NOT IN", "NOT LIKE" or "NOT BETWEEN" ending at j
+func notBefore(sqlText string, j int) int {
+	if word, k := wordBefore(sqlText, j); word == kwNOT {
+		return k
+	}
+	return j
+}
+
+// nameBefore return the column name, quoted or not, ending at j after spaces
+func nameBefore(sqlText string, j int) string {
+	j = spaceBefore(sqlText, j)
+	if j < 0 {
+		return ""
+	}
+	if q := sqlText[j]; q == '"' || q == '`' || q == ']' {
+		open := q
+		if q == ']' {
+			open = '['
+		}
+		start := strings.LastIndexByte(sqlText[:j], open)
+		if start < 0 {
+			return ""
+		}
+		return sqlText[start+1 : j]
+	}
+	end := j + 1
+	for j >= 0 && isNameByte(sqlText[j]) {
+		j--
+	}
+	return sqlText[j+1 : end]
+}
+
+// insertColumns return the column list of an INSERT statement and the position of its VALUES keyword
+func insertColumns(sqlText string) ([]string, int) {
+	upper := strings.ToUpper(sqlText)
+	if !strings.HasPrefix(strings.TrimSpace(upper), kwINSERT) {
+		return nil, 0
+	}
+	open := strings.Index(sqlText, "(")
+	values := strings.Index(upper, kwVALUES)
+	if open < 0 || values < open {
+		return nil, 0
+	}
+	end := strings.LastIndex(sqlText[:values], ")")
+	if end < open {
+		return nil, 0
+	}
+	var cols []string
+	for _, col := range strings.Split(sqlText[open+1:end], ",") {
+		col = strings.TrimSpace(col)
+		cols = append(cols, strings.Trim(col, "\"`[]"))
+	}
+	return cols, values
+}
@@ -0,0 +1,560 @@
+package gqbuilder
+
+/*
+	read the schema of a live database: information_schema on MySQL and PostgreSQL, sqlite_master and
+	PRAGMA on SQLite
+*/
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ColumnInfo describes a column of a table
+type ColumnInfo struct {
+	Name     string
+	Type     string
+	Nullable bool
+	Default  sql.NullString
+	Position int
+}
+
+// IndexInfo describes an index of a table, Columns are in the order of the index
+type IndexInfo struct {
+	Name    string
+	Columns []string
+	Unique  bool
+	Primary bool
+}
+
+// ForeignKeyInfo describes a foreign key of a table. SQLite doesn't name foreign keys, their Name is empty
+type ForeignKeyInfo struct {
+	Name       string
+	Columns    []string
+	RefTable   string
+	RefColumns []string
+	OnDelete   string
+	OnUpdate   string
+}
+
+// Inspector reads the schema of a builder's database. Tables are looked up in the current schema or database,
+// a name like "archive.users" looks up another one
+type Inspector struct {
+	builder *Builder
+}
+
+// Inspector return the schema reader of the builder's database
+func (b *Builder) Inspector() *Inspector {
+	return &Inspector{builder: b}
+}
+
+// splitSchema return the schema expression and the table name of a possibly qualified table
+func (i *Inspector) splitSchema(table string) (schema string, args []interface{}, name string) {
+	if p := strings.LastIndex(table, "."); p >= 0 {
+		return "?", []interface{}{table[:p]}, table[p+1:]
+	}
+	if i.builder.driver == MySQL {
+		return "DATABASE()", nil, table
+	}
+	return "current_schema()", nil, table
+}
+
+// rows run a hand-written query, "?" markers are rewritten in the builder's pattern
+func (i *Inspector) rows(ctx context.Context, sqlText string, args ...interface{}) (*sql.Rows, error) {
+	rst, err := i.builder.Raw(sqlText, args...)
+	if err != nil {
+		return nil, err
+	}
+	raw, values := rst.ToPrepared()
+	return i.builder.query(ctx, stmtInfo{method: "select", primary: true}, raw, values)
+}
+
+// pragma run a PRAGMA of table on SQLite and return its rows as maps keyed by column name
+func (i *Inspector) pragma(ctx context.Context, name, table string) ([]map[string]interface{}, error) {
+	c := i.builder.cmpl.base()
+	stmt := "PRAGMA " + name + "(" + c.wrapWord(table) + ")"
+	if p := strings.LastIndex(table, "."); p >= 0 {
+		// the schema qualifies the pragma, e.g. PRAGMA main.table_info("users")
+		stmt = "PRAGMA " + c.wrapWord(table[:p]) + "." + name + "(" + c.wrapWord(table[p+1:]) + ")"
+	}
+	rows, err := i.builder.query(ctx, stmtInfo{method: "select", table: table, primary: true}, stmt, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	var list []map[string]interface{}
+	for rows.Next() {
+		values := make([]interface{}, len(cols))
+		ptrs := make([]interface{}, len(cols))
+		for k := range values {
+			ptrs[k] = &values[k]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		row := make(map[string]interface{}, len(cols))
+		for k, col := range cols {
+			if b, ok := values[k].([]byte); ok {
+				values[k] = string(b)
+			}
+			row[col] = values[k]
+		}
+		list = append(list, row)
+	}
+	return list, rows.Err()
+}
+
+func pragmaString(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+func pragmaInt(v interface{}) int64 {
+	switch n := v.(type) {
+	case int64:
+		return n
+	case int:
+		return int64(n)
+	case bool:
+		if n {
+			return 1
+		}
+	}
+	return 0
+}
+
+// Tables return the names of the tables by name
+func (i *Inspector) Tables(ctx context.Context) ([]string, error) {
+	var rows *sql.Rows
+	var err error
+	switch i.builder.driver {
+	case SQLite:
+		rows, err = i.rows(ctx, "SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name")
+	case MySQL:
+		rows, err = i.rows(ctx, "SELECT table_name FROM information_schema.tables "+
+			"WHERE table_schema = DATABASE() AND table_type = 'BASE TABLE' ORDER BY table_name")
+	default:
+		rows, err = i.rows(ctx, "SELECT table_name FROM information_schema.tables "+
+			"WHERE table_schema = current_schema() AND table_type = 'BASE TABLE' ORDER BY table_name")
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		tables = append(tables, name)
+	}
+	return tables, rows.Err()
+}
+
+// HasTable reports whether the table exists
+func (i *Inspector) HasTable(ctx context.Context, table string) (bool, error) {
+	cols, err := i.Columns(ctx, table)
+	return len(cols) > 0, err
+}
+
+// Columns return the columns of table by position, a table that doesn't exist has no columns
+func (i *Inspector) Columns(ctx context.Context, table string) ([]ColumnInfo, error) {
+	if i.builder.driver == SQLite {
+		list, err := i.pragma(ctx, "table_info", table)
+		if err != nil {
+			return nil, err
+		}
+		cols := make([]ColumnInfo, 0, len(list))
+		for _, row := range list {
+			col := ColumnInfo{
+				Name:     pragmaString(row["name"]),
+				Type:     pragmaString(row["type"]),
+				Nullable: pragmaInt(row["notnull"]) == 0 && pragmaInt(row["pk"]) == 0,
+				Position: int(pragmaInt(row["cid"])) + 1,
+			}
+			if row["dflt_value"] != nil {
+				col.Default = sql.NullString{String: pragmaString(row["dflt_value"]), Valid: true}
+			}
+			cols = append(cols, col)
+		}
+		return cols, nil
+	}
+
+	schema, args, name := i.splitSchema(table)
+	typ := "data_type"
+	if i.builder.driver == MySQL {
+		typ = "column_type"
+	}
+	rows, err := i.rows(ctx, "SELECT column_name, "+typ+", is_nullable, column_default, ordinal_position "+
+		"FROM information_schema.columns WHERE table_schema = "+schema+" AND table_name = ? ORDER BY ordinal_position",
+		append(args, name)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var cols []ColumnInfo
+	for rows.Next() {
+		var col ColumnInfo
+		var nullable string
+		if err := rows.Scan(&col.Name, &col.Type, &nullable, &col.Default, &col.Position); err != nil {
+			return nil, err
+		}
+		col.Nullable = nullable == "YES"
+		cols = append(cols, col)
+	}
+	return cols, rows.Err()
+}
+
+// HasColumn reports whether the table has the column
+func (i *Inspector) HasColumn(ctx context.Context, table, column string) (bool, error) {
+	cols, err := i.Columns(ctx, table)
+	if err != nil {
+		return false, err
+	}
+	for _, col := range cols {
+		if col.Name == column {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// PrimaryKey return the columns of the primary key of table in key order, nil without primary key
+func (i *Inspector) PrimaryKey(ctx context.Context, table string) ([]string, error) {
+	if i.builder.driver == SQLite {
+		list, err := i.pragma(ctx, "table_info", table)
+		if err != nil {
+			return nil, err
+		}
+		sort.SliceStable(list, func(a, b int) bool { return pragmaInt(list[a]["pk"]) < pragmaInt(list[b]["pk"]) })
+		var key []string
+		for _, row := range list {
+			if pragmaInt(row["pk"]) > 0 {
+				key = append(key, pragmaString(row["name"]))
+			}
+		}
+		return key, nil
+	}
+
+	schema, args, name := i.splitSchema(table)
+	rows, err := i.rows(ctx, "SELECT kcu.column_name FROM information_schema.table_constraints tc "+
+		"JOIN information_schema.key_column_usage kcu ON kcu.constraint_name = tc.constraint_name "+
+		"AND kcu.table_schema = tc.table_schema AND kcu.table_name = tc.table_name "+
+		"WHERE tc.constraint_type = 'PRIMARY KEY' AND tc.table_schema = "+schema+" AND tc.table_name = ? "+
+		"ORDER BY kcu.ordinal_position", append(args, name)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var key []string
+	for rows.Next() {
+		var col string
+		if err := rows.Scan(&col); err != nil {
+			return nil, err
+		}
+		key = append(key, col)
+	}
+	return key, rows.Err()
+}
+
+// Indexes return the indexes of table by name, including the index of the primary key
+func (i *Inspector) Indexes(ctx context.Context, table string) ([]IndexInfo, error) {
+	if i.builder.driver == SQLite {
+		list, err := i.pragma(ctx, "index_list", table)
+		if err != nil {
+			return nil, err
+		}
+		var indexes []IndexInfo
+		for _, row := range list {
+			idx := IndexInfo{
+				Name:    pragmaString(row["name"]),
+				Unique:  pragmaInt(row["unique"]) == 1,
+				Primary: pragmaString(row["origin"]) == "pk",
+			}
+			cols, err := i.pragma(ctx, "index_info", idx.Name)
+			if err != nil {
+				return nil, err
+			}
+			sort.SliceStable(cols, func(a, b int) bool { return pragmaInt(cols[a]["seqno"]) < pragmaInt(cols[b]["seqno"]) })
+			for _, col := range cols {
+				idx.Columns = append(idx.Columns, pragmaString(col["name"]))
+			}
+			indexes = append(indexes, idx)
+		}
+		sort.Slice(indexes, func(a, b int) bool { return indexes[a].Name < indexes[b].Name })
+		return indexes, nil
+	}
+
+	schema, args, name := i.splitSchema(table)
+	var rows *sql.Rows
+	var err error
+	if i.builder.driver == MySQL {
+		rows, err = i.rows(ctx, "SELECT index_name, column_name, non_unique = 0, index_name = 'PRIMARY' "+
+			"FROM information_schema.statistics WHERE table_schema = "+schema+" AND table_name = ? "+
+			"ORDER BY index_name, seq_in_index", append(args, name)...)
+	} else {
+		// information_schema has no indexes, read the catalog of PostgreSQL
+		rows, err = i.rows(ctx, "SELECT ic.relname, a.attname, ix.indisunique, ix.indisprimary "+
+			"FROM pg_index ix JOIN pg_class tc ON tc.oid = ix.indrelid JOIN pg_class ic ON ic.oid = ix.indexrelid "+
+			"JOIN pg_namespace n ON n.oid = tc.relnamespace "+
+			"JOIN LATERAL unnest(ix.indkey) WITH ORDINALITY AS k(attnum, ord) ON true "+
+			"JOIN pg_attribute a ON a.attrelid = tc.oid AND a.attnum = k.attnum "+
+			"WHERE n.nspname = "+schema+" AND tc.relname = ? ORDER BY ic.relname, k.ord", append(args, name)...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var indexes []IndexInfo
+	for rows.Next() {
+		var idxName, col string
+		var unique, primary bool
+		if err := rows.Scan(&idxName, &col, &unique, &primary); err != nil {
+			return nil, err
+		}
+		if n := len(indexes); n > 0 && indexes[n-1].Name == idxName {
+			indexes[n-1].Columns = append(indexes[n-1].Columns, col)
+			continue
+		}
+		indexes = append(indexes, IndexInfo{Name: idxName, Columns: []string{col}, Unique: unique, Primary: primary})
+	}
+	return indexes, rows.Err()
+}
+
+// ForeignKeys return the foreign keys of table
+func (i *Inspector) ForeignKeys(ctx context.Context, table string) ([]ForeignKeyInfo, error) {
+	if i.builder.driver == SQLite {
+		list, err := i.pragma(ctx, "foreign_key_list", table)
+		if err != nil {
+			return nil, err
+		}
+		var fks []ForeignKeyInfo
+		ids := make(map[int64]int)
+		for _, row := range list {
+			id := pragmaInt(row["id"])
+			k, ok := ids[id]
+			if !ok {
+				fks = append(fks, ForeignKeyInfo{
+					RefTable: pragmaString(row["table"]),
+					OnDelete: pragmaString(row["on_delete"]),
+					OnUpdate: pragmaString(row["on_update"]),
+				})
+				k = len(fks) - 1
+				ids[id] = k
+			}
+			fks[k].Columns = append(fks[k].Columns, pragmaString(row["from"]))
+			fks[k].RefColumns = append(fks[k].RefColumns, pragmaString(row["to"]))
+		}
+		return fks, nil
+	}
+
+	schema, args, name := i.splitSchema(table)
+	var rows *sql.Rows
+	var err error
+	if i.builder.driver == MySQL {
+		rows, err = i.rows(ctx, "SELECT kcu.constraint_name, kcu.column_name, kcu.referenced_table_name, "+
+			"kcu.referenced_column_name, rc.delete_rule, rc.update_rule FROM information_schema.key_column_usage kcu "+
+			"JOIN information_schema.referential_constraints rc ON rc.constraint_schema = kcu.constraint_schema "+
+			"AND rc.constraint_name = kcu.constraint_name AND rc.table_name = kcu.table_name "+
+			"WHERE kcu.table_schema = "+schema+" AND kcu.table_name = ? AND kcu.referenced_table_name IS NOT NULL "+
+			"ORDER BY kcu.constraint_name, kcu.ordinal_position", append(args, name)...)
+	} else {
+		rows, err = i.rows(ctx, "SELECT kcu.constraint_name, kcu.column_name, rcu.table_name, rcu.column_name, "+
+			"rc.delete_rule, rc.update_rule FROM information_schema.referential_constraints rc "+
+			"JOIN information_schema.key_column_usage kcu ON kcu.constraint_schema = rc.constraint_schema "+
+			"AND kcu.constraint_name = rc.constraint_name "+
+			"JOIN information_schema.key_column_usage rcu ON rcu.constraint_schema = rc.unique_constraint_schema "+
+			"AND rcu.constraint_name = rc.unique_constraint_name AND rcu.ordinal_position = kcu.position_in_unique_constraint "+
+			"WHERE kcu.table_schema = "+schema+" AND kcu.table_name = ? "+
+			"ORDER BY kcu.constraint_name, kcu.ordinal_position", append(args, name)...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var fks []ForeignKeyInfo
+	for rows.Next() {
+		var fkName, col, refTable, refCol, onDelete, onUpdate string
+		if err := rows.Scan(&fkName, &col, &refTable, &refCol, &onDelete, &onUpdate); err != nil {
+			return nil, err
+		}
+		if n := len(fks); n > 0 && fks[n-1].Name == fkName {
+			fks[n-1].Columns = append(fks[n-1].Columns, col)
+			fks[n-1].RefColumns = append(fks[n-1].RefColumns, refCol)
+			continue
+		}
+		fks = append(fks, ForeignKeyInfo{
+			Name: fkName, Columns: []string{col}, RefTable: refTable, RefColumns: []string{refCol},
+			OnDelete: onDelete, OnUpdate: onUpdate,
+		})
+	}
+	return fks, rows.Err()
+}
+
+// UnknownColumnError is returned by Validate for a column the live schema doesn't have
+type UnknownColumnError struct {
+	Table  string
+	Column string
+}
+
+func (e *UnknownColumnError) Error() string {
+	if e.Table == "" {
+		return "column " + e.Column + " is not in any table of the query"
+	}
+	return "table " + e.Table + " has no column " + e.Column
+}
+
+// Validate check the column names of q against the live schema. Names qualified by a table or alias are
+// looked up in that table, others in any table of the query. Raw expressions and sub queries aren't checked
+func (i *Inspector) Validate(ctx context.Context, q *Query) error {
+	tables := make(map[string]string) // alias or name -> table
+	var order []string
+	addTable := func(mixture string) {
+		name, alias := q.splitAlias(mixture)
+		if alias == "" {
+			alias = name
+		}
+		tables[alias] = name
+		order = append(order, alias)
+	}
+	for _, elm := range q.elements {
+		switch cls := elm.(type) {
+		case fromClause:
+			alias := cls.alias
+			if alias == "" {
+				alias = cls.tableName
+			}
+			tables[alias] = cls.tableName
+			order = append(order, alias)
+		case joinClause:
+			addTable(cls.table)
+		}
+	}
+
+	columns := make(map[string]map[string]bool)
+	lookup := func(table string) (map[string]bool, error) {
+		if cols, ok := columns[table]; ok {
+			return cols, nil
+		}
+		list, err := i.Columns(ctx, table)
+		if err != nil {
+			return nil, err
+		}
+		cols := make(map[string]bool, len(list))
+		for _, col := range list {
+			cols[col.Name] = true
+		}
+		columns[table] = cols
+		return cols, nil
+	}
+
+	aliases := make(map[string]bool)
+	for _, name := range queryColumns(q, aliases) {
+		if aliases[name] {
+			continue
+		}
+		if p := strings.LastIndex(name, "."); p >= 0 {
+			qualifier, col := name[:p], name[p+1:]
+			table, ok := tables[qualifier]
+			if !ok {
+				continue
+			}
+			cols, err := lookup(table)
+			if err != nil {
+				return err
+			}
+			if !cols[col] && col != kwALL {
+				return &UnknownColumnError{Table: table, Column: col}
+			}
+			continue
+		}
+		found := false
+		for _, alias := range order {
+			cols, err := lookup(tables[alias])
+			if err != nil {
+				return err
+			}
+			if cols[name] {
+				found = true
+				break
+			}
+		}
+		if !found {
+			if len(order) == 1 {
+				return &UnknownColumnError{Table: tables[order[0]], Column: name}
+			}
+			return &UnknownColumnError{Column: name}
+		}
+	}
+	return nil
+}
+
+// queryColumns return the column names q refers to, aliases get the aliases of the select list
+func queryColumns(q *Query, aliases map[string]bool) []string {
+	var names []string
+	add := func(cols ...string) {
+		for _, col := range cols {
+			if col != "" && col != kwALL {
+				names = append(names, col)
+			}
+		}
+	}
+	for _, elm := range q.elements {
+		switch cls := elm.(type) {
+		case columnClause:
+			add(cls.name)
+			if cls.alias != "" {
+				aliases[cls.alias] = true
+			}
+		case subColumnClause:
+			aliases[cls.alias] = true
+		case groupingColumnClause:
+			add(cls.columnNames...)
+			if cls.alias != "" {
+				aliases[cls.alias] = true
+			}
+		case joinClause:
+			add(cls.left, cls.right)
+		case compareCondition:
+			add(cls.columnName)
+		case columnCompareCondition:
+			add(cls.leftColumn, cls.rightColumn)
+		case likeCondition:
+			add(cls.columnName)
+		case betweenCondition:
+			add(cls.columnName)
+		case inCondition:
+			add(cls.columnName)
+		case inQueryCondition:
+			add(cls.columnName)
+		case nullCondition:
+			add(cls.columnName)
+		case booleanCondition:
+			add(cls.columnName)
+		case orderByClause:
+			add(cls.columnName)
+		case fieldOrderClause:
+			add(cls.columnName)
+		case groupByClause:
+			add(cls.columnNames...)
+			for _, set := range cls.sets {
+				add(set...)
+			}
+		case insertClause:
+			add(cls.columns...)
+		case updateClause:
+			add(cls.columns...)
+		}
+	}
+	return names
+}
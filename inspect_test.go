@@ -0,0 +1,87 @@
+package gqbuilder
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/paulnjiang/gqbuilder/internal/fakedb"
+)
+
+func TestInspectorSQLite(t *testing.T) {
+	fake, con := fakedb.New("inspect")
+	fake.Rows = func(query string) ([]string, [][]driver.Value) {
+		switch {
+		case strings.Contains(query, "table_info"):
+			return []string{"cid", "name", "type", "notnull", "dflt_value", "pk"}, [][]driver.Value{
+				{int64(0), "id", "INTEGER", int64(0), nil, int64(1)},
+				{int64(1), "email", "VARCHAR(255)", int64(1), nil, int64(0)},
+				{int64(2), "team_id", "INTEGER", int64(0), "0", int64(0)},
+			}
+		case strings.Contains(query, "index_list"):
+			return []string{"seq", "name", "unique", "origin", "partial"}, [][]driver.Value{
+				{int64(0), "users_email_unique", int64(1), "c", int64(0)},
+			}
+		case strings.Contains(query, "index_info"):
+			return []string{"seqno", "cid", "name"}, [][]driver.Value{{int64(0), int64(1), "email"}}
+		case strings.Contains(query, "foreign_key_list"):
+			return []string{"id", "seq", "table", "from", "to", "on_update", "on_delete", "match"}, [][]driver.Value{
+				{int64(0), int64(0), "teams", "team_id", "id", "NO ACTION", "CASCADE", "NONE"},
+			}
+		}
+		return []string{"name"}, [][]driver.Value{{"teams"}, {"users"}}
+	}
+	ctx := context.Background()
+	ins := NewBuilder(SQLite, con).Inspector()
+
+	tables, err := ins.Tables(ctx)
+	if err != nil || strings.Join(tables, ",") != "teams,users" {
+		t.Errorf("test inspector tables: unexpected %v %v\n", tables, err)
+	}
+	cols, err := ins.Columns(ctx, "users")
+	if err != nil || len(cols) != 3 || cols[0].Nullable || cols[1].Nullable || !cols[2].Nullable ||
+		cols[2].Default.String != "0" || cols[1].Position != 2 {
+		t.Errorf("test inspector columns: unexpected %+v %v\n", cols, err)
+	}
+	if fake.Queries[1] != `PRAGMA table_info("users")` {
+		t.Errorf("test inspector columns: unexpected sql %s\n", fake.Queries[1])
+	}
+	key, err := ins.PrimaryKey(ctx, "users")
+	if err != nil || strings.Join(key, ",") != "id" {
+		t.Errorf("test inspector primary key: unexpected %v %v\n", key, err)
+	}
+	idx, err := ins.Indexes(ctx, "users")
+	if err != nil || len(idx) != 1 || !idx[0].Unique || strings.Join(idx[0].Columns, ",") != "email" {
+		t.Errorf("test inspector indexes: unexpected %+v %v\n", idx, err)
+	}
+	fks, err := ins.ForeignKeys(ctx, "users")
+	if err != nil || len(fks) != 1 || fks[0].RefTable != "teams" || fks[0].OnDelete != "CASCADE" {
+		t.Errorf("test inspector foreign keys: unexpected %+v %v\n", fks, err)
+	}
+
+	bdr := NewBuilder(SQLite, con)
+	if err := ins.Validate(ctx, bdr.Query("users as u").Select("u.id", "email as mail").Where("team_id", "=", 1).OrderBy("mail")); err != nil {
+		t.Errorf("test inspector validate error: %s\n", err)
+	}
+	err = ins.Validate(ctx, bdr.Query("users").Select("id").Where("emial", "=", "a"))
+	var unknown *UnknownColumnError
+	if !errors.As(err, &unknown) || unknown.Column != "emial" || unknown.Table != "users" {
+		t.Errorf("test inspector validate: expect an unknown column error, got %v\n", err)
+	}
+}
+
+func TestInspectorPostgreSQL(t *testing.T) {
+	fake, con := fakedb.New("inspect_pg")
+	ins := NewBuilder(PostgreSQL, con).Inspector()
+	if _, err := ins.Columns(context.Background(), "archive.users"); err != nil {
+		t.Errorf("test inspector postgresql error: %s\n", err)
+		return
+	}
+	expect := "SELECT column_name, data_type, is_nullable, column_default, ordinal_position FROM information_schema.columns " +
+		"WHERE table_schema = $1 AND table_name = $2 ORDER BY ordinal_position"
+	if fake.Queries[0] != expect {
+		t.Errorf("test inspector postgresql: unexpected sql %s\n", fake.Queries[0])
+	}
+}
@@ -0,0 +1,256 @@
+package gqbuilder
+
+/*
+	tracing and metrics built on hooks, with in-memory implementations for tests
+*/
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// names of the metrics recorded by MetricsHook
+const (
+	MetricQueryDuration = "gqb_query_duration_seconds" // histogram
+	MetricQueries       = "gqb_queries_total"          // counter
+	MetricQueryErrors   = "gqb_query_errors_total"     // counter
+	MetricRowsAffected  = "gqb_rows_affected_total"    // counter
+)
+
+// Labels describe a statement for tracing and metrics. Statement is the normalized sql, values are replaced
+// with "?" so the statements of a query share it
+type Labels struct {
+	Dialect   string
+	Operation string
+	Table     string
+	Statement string
+}
+
+func eventLabels(ev *QueryEvent) Labels {
+	return Labels{
+		Dialect:   ev.Dialect.String(),
+		Operation: ev.Method,
+		Table:     ev.Table,
+		Statement: normalizeSQL(ev.SQL),
+	}
+}
+
+// Span is a traced statement
+type Span interface {
+	SetError(err error)
+	End()
+}
+
+// Tracer starts a span for every statement
+type Tracer interface {
+	Start(ctx context.Context, name string, labels Labels) (context.Context, Span)
+}
+
+// Metrics records histograms and counters keyed by name and labels
+type Metrics interface {
+	Observe(name string, labels Labels, value float64)
+	Add(name string, labels Labels, delta float64)
+}
+
+type spanKey struct{}
+
+// TracingHook starts a span named like "select users" before every statement and ends it after
+type TracingHook struct {
+	tracer Tracer
+}
+
+// NewTracingHook return a hook tracing statements with tracer
+func NewTracingHook(tracer Tracer) *TracingHook {
+	return &TracingHook{tracer: tracer}
+}
+
+// Before start the span
+func (h *TracingHook) Before(ctx context.Context, ev *QueryEvent) context.Context {
+	labels := eventLabels(ev)
+	name := labels.Operation
+	if labels.Table != "" {
+		name += " " + labels.Table
+	}
+	ctx, span := h.tracer.Start(ctx, name, labels)
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// After end the span
+func (h *TracingHook) After(ctx context.Context, ev *QueryEvent) {
+	span, ok := ctx.Value(spanKey{}).(Span)
+	if !ok {
+		return
+	}
+	if ev.Err != nil {
+		span.SetError(ev.Err)
+	}
+	span.End()
+}
+
+// MetricsHook records the duration, count, errors and rows affected of statements
+type MetricsHook struct {
+	metrics Metrics
+}
+
+// NewMetricsHook return a hook recording to metrics
+func NewMetricsHook(metrics Metrics) *MetricsHook {
+	return &MetricsHook{metrics: metrics}
+}
+
+// Before does nothing
+func (h *MetricsHook) Before(ctx context.Context, ev *QueryEvent) context.Context {
+	return ctx
+}
+
+// After record the statement
+func (h *MetricsHook) After(ctx context.Context, ev *QueryEvent) {
+	labels := eventLabels(ev)
+	h.metrics.Observe(MetricQueryDuration, labels, ev.Duration.Seconds())
+	h.metrics.Add(MetricQueries, labels, 1)
+	if ev.Err != nil {
+		h.metrics.Add(MetricQueryErrors, labels, 1)
+	}
+	if ev.RowsAffected > 0 {
+		h.metrics.Add(MetricRowsAffected, labels, float64(ev.RowsAffected))
+	}
+}
+
+// MemorySpan is a span recorded by MemoryTracer
+type MemorySpan struct {
+	Name   string
+	Labels Labels
+	Start  time.Time
+	End    time.Time
+	Err    error
+	Ended  bool
+}
+
+// MemoryTracer is a Tracer keeping its spans in memory, for tests
+type MemoryTracer struct {
+	mu    sync.Mutex
+	spans []*MemorySpan
+}
+
+// NewMemoryTracer return an empty MemoryTracer
+func NewMemoryTracer() *MemoryTracer {
+	return new(MemoryTracer)
+}
+
+// Start record a span
+func (t *MemoryTracer) Start(ctx context.Context, name string, labels Labels) (context.Context, Span) {
+	sp := &MemorySpan{Name: name, Labels: labels, Start: time.Now()}
+	t.mu.Lock()
+	t.spans = append(t.spans, sp)
+	t.mu.Unlock()
+	return ctx, memorySpan{t, sp}
+}
+
+// Spans return a copy of the spans in start order
+func (t *MemoryTracer) Spans() []MemorySpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	spans := make([]MemorySpan, 0, len(t.spans))
+	for _, sp := range t.spans {
+		spans = append(spans, *sp)
+	}
+	return spans
+}
+
+// Reset drop the spans
+func (t *MemoryTracer) Reset() {
+	t.mu.Lock()
+	t.spans = nil
+	t.mu.Unlock()
+}
+
+type memorySpan struct {
+	tracer *MemoryTracer
+	span   *MemorySpan
+}
+
+func (s memorySpan) SetError(err error) {
+	s.tracer.mu.Lock()
+	s.span.Err = err
+	s.tracer.mu.Unlock()
+}
+
+func (s memorySpan) End() {
+	s.tracer.mu.Lock()
+	s.span.End = time.Now()
+	s.span.Ended = true
+	s.tracer.mu.Unlock()
+}
+
+type metricKey struct {
+	name   string
+	labels Labels
+}
+
+// MemoryMetrics is a Metrics keeping histograms and counters in memory, for tests
+type MemoryMetrics struct {
+	mu         sync.Mutex
+	counters   map[metricKey]float64
+	histograms map[metricKey][]float64
+}
+
+// NewMemoryMetrics return an empty MemoryMetrics
+func NewMemoryMetrics() *MemoryMetrics {
+	return &MemoryMetrics{
+		counters:   make(map[metricKey]float64),
+		histograms: make(map[metricKey][]float64),
+	}
+}
+
+// Observe add value to a histogram
+func (m *MemoryMetrics) Observe(name string, labels Labels, value float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	k := metricKey{name, labels}
+	m.histograms[k] = append(m.histograms[k], value)
+}
+
+// Add add delta to a counter
+func (m *MemoryMetrics) Add(name string, labels Labels, delta float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counters[metricKey{name, labels}] += delta
+}
+
+// Counter return the value of a counter
+func (m *MemoryMetrics) Counter(name string, labels Labels) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.counters[metricKey{name, labels}]
+}
+
+// Histogram return a copy of the values observed by a histogram
+func (m *MemoryMetrics) Histogram(name string, labels Labels) []float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]float64(nil), m.histograms[metricKey{name, labels}]...)
+}
+
+// LabelSets return the labels recorded under name, sorted by statement
+func (m *MemoryMetrics) LabelSets(name string) []Labels {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	seen := make(map[Labels]bool)
+	for k := range m.counters {
+		if k.name == name {
+			seen[k.labels] = true
+		}
+	}
+	for k := range m.histograms {
+		if k.name == name {
+			seen[k.labels] = true
+		}
+	}
+	sets := make([]Labels, 0, len(seen))
+	for l := range seen {
+		sets = append(sets, l)
+	}
+	sort.Slice(sets, func(i, j int) bool { return sets[i].Statement < sets[j].Statement })
+	return sets
+}
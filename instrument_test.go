@@ -0,0 +1,51 @@
+package gqbuilder
+
+import (
+	"context"
+	"testing"
+
+	"github.com/paulnjiang/gqbuilder/internal/fakedb"
+)
+
+func TestInstrumentation(t *testing.T) {
+	fake, con := fakedb.New("instrument")
+	fake.Affected = func(string) int64 { return 2 }
+	tracer := NewMemoryTracer()
+	metrics := NewMemoryMetrics()
+	bdr := NewBuilder(MySQL, con).AddHook(NewTracingHook(tracer), NewMetricsHook(metrics))
+	ctx := context.Background()
+
+	for _, ids := range [][]interface{}{{1, 2}, {3, 4, 5}} {
+		if _, err := bdr.Query("user").Select("id").WhereIn("id", ids...).Get(ctx); err != nil {
+			t.Errorf("test instrumentation error: %s\n", err)
+			return
+		}
+	}
+	if _, err := bdr.Query("user").Where("id", "=", 1).Delete().Exec(ctx); err != nil {
+		t.Errorf("test instrumentation error: %s\n", err)
+		return
+	}
+
+	spans := tracer.Spans()
+	if len(spans) != 3 || spans[0].Name != "select user" || spans[2].Name != "delete user" || !spans[2].Ended {
+		t.Errorf("test instrumentation: unexpected spans %+v\n", spans)
+	}
+	sel := Labels{Dialect: "MySQL", Operation: "select", Table: "user", Statement: "SELECT `id` FROM `user` WHERE `id` IN (?)"}
+	if spans[0].Labels != sel || spans[1].Labels != sel {
+		t.Errorf("test instrumentation: unexpected labels %+v\n", spans[0].Labels)
+	}
+	if metrics.Counter(MetricQueries, sel) != 2 || len(metrics.Histogram(MetricQueryDuration, sel)) != 2 {
+		t.Errorf("test instrumentation: unexpected select metrics %v\n", metrics.LabelSets(MetricQueries))
+	}
+	del := Labels{Dialect: "MySQL", Operation: "delete", Table: "user", Statement: "DELETE FROM `user` WHERE `id` = ?"}
+	if metrics.Counter(MetricRowsAffected, del) != 2 || metrics.Counter(MetricQueryErrors, del) != 0 {
+		t.Errorf("test instrumentation: unexpected delete metrics %v\n", metrics.LabelSets(MetricRowsAffected))
+	}
+
+	fake.SetDown(true)
+	tracer.Reset()
+	bdr.Query("user").Where("id", "=", 1).Delete().Exec(ctx)
+	if spans = tracer.Spans(); len(spans) != 1 || spans[0].Err == nil || metrics.Counter(MetricQueryErrors, del) != 1 {
+		t.Errorf("test instrumentation: expect a failed span and an error count %+v\n", spans)
+	}
+}
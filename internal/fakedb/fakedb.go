@@ -0,0 +1,177 @@
+// Package fakedb is an in-memory database/sql driver for the tests of gqbuilder and its sub packages
+package fakedb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"sync"
+)
+
+// DB is a database/sql connector which records the statements it receives
+type DB struct {
+	mu       sync.Mutex
+	Name     string
+	Prepares int
+	Closes   int
+	Execs    []string
+	Queries  []string
+	down     bool
+	// Affected return the rows affected by an exec, default 0
+	Affected func(query string) int64
+	// Rows return the columns and rows of a query, default one column "n" without rows
+	Rows func(query string) ([]string, [][]driver.Value)
+	// Err return the error of an exec or a query, default nil
+	Err func(query string) error
+}
+
+// New return the fake database and a *sql.DB using it
+func New(name string) (*DB, *sql.DB) {
+	f := &DB{Name: name}
+	return f, sql.OpenDB(f)
+}
+
+func (f *DB) Connect(ctx context.Context) (driver.Conn, error) {
+	return &fakeConn{f}, nil
+}
+
+func (f *DB) Driver() driver.Driver {
+	return fakeDriver{f}
+}
+
+// SetDown make every statement and ping fail with driver.ErrBadConn until it is called with false
+func (f *DB) SetDown(down bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.down = down
+}
+
+func (f *DB) check() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.down {
+		return driver.ErrBadConn
+	}
+	return nil
+}
+
+type fakeDriver struct {
+	db *DB
+}
+
+func (d fakeDriver) Open(name string) (driver.Conn, error) {
+	return &fakeConn{d.db}, nil
+}
+
+type fakeConn struct {
+	db *DB
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	if err := c.db.check(); err != nil {
+		return nil, err
+	}
+	c.db.mu.Lock()
+	c.db.Prepares++
+	c.db.mu.Unlock()
+	return &fakeStmt{c.db, query}, nil
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return fakeTx{}, nil
+}
+
+func (c *fakeConn) Ping(ctx context.Context) error {
+	return c.db.check()
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error {
+	return nil
+}
+
+func (fakeTx) Rollback() error {
+	return nil
+}
+
+type fakeStmt struct {
+	db    *DB
+	query string
+}
+
+func (s *fakeStmt) Close() error {
+	s.db.mu.Lock()
+	s.db.Closes++
+	s.db.mu.Unlock()
+	return nil
+}
+
+func (s *fakeStmt) NumInput() int {
+	return -1
+}
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if err := s.db.check(); err != nil {
+		return nil, err
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	s.db.Execs = append(s.db.Execs, s.query)
+	if s.db.Err != nil {
+		if err := s.db.Err(s.query); err != nil {
+			return nil, err
+		}
+	}
+	var n int64
+	if s.db.Affected != nil {
+		n = s.db.Affected(s.query)
+	}
+	return driver.RowsAffected(n), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if err := s.db.check(); err != nil {
+		return nil, err
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	s.db.Queries = append(s.db.Queries, s.query)
+	if s.db.Err != nil {
+		if err := s.db.Err(s.query); err != nil {
+			return nil, err
+		}
+	}
+	if s.db.Rows != nil {
+		cols, data := s.db.Rows(s.query)
+		return &fakeRows{cols: cols, data: data}, nil
+	}
+	return &fakeRows{cols: []string{"n"}}, nil
+}
+
+type fakeRows struct {
+	cols []string
+	data [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.cols
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.data) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.data[0])
+	r.data = r.data[1:]
+	return nil
+}
@@ -0,0 +1,182 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/paulnjiang/gqbuilder"
+)
+
+// ErrLocked is returned when another migrator holds the lock longer than the lock timeout
+var ErrLocked = errors.New("migrate: lock is held by another migrator")
+
+// locker takes the migration lock on a connection: pg_advisory_lock on PostgreSQL, GET_LOCK on MySQL and a
+// row of the table "<table>_lock" elsewhere. A row older than stale is left by a dead migrator and is
+// deleted
+type locker struct {
+	builder *gqbuilder.Builder
+	name    string
+	timeout time.Duration
+	stale   time.Duration
+}
+
+func newLocker(bdr *gqbuilder.Builder, table string, timeout, stale time.Duration) *locker {
+	return &locker{builder: bdr, name: table + "_lock", timeout: timeout, stale: stale}
+}
+
+// key return the advisory lock key of PostgreSQL
+func (l *locker) key() int64 {
+	h := fnv.New64a()
+	h.Write([]byte(l.name))
+	return int64(h.Sum64())
+}
+
+func (l *locker) exec(ctx context.Context, conn *sql.Conn, sqlText string, args ...interface{}) error {
+	rst, err := l.builder.Raw(sqlText, args...)
+	if err != nil {
+		return err
+	}
+	raw, values := rst.ToPrepared()
+	_, err = conn.ExecContext(ctx, raw, values...)
+	return err
+}
+
+func (l *locker) lock(ctx context.Context, conn *sql.Conn) error {
+	switch l.builder.Dialect() {
+	case gqbuilder.PostgreSQL:
+		ctx, cancel := context.WithTimeout(ctx, l.timeout)
+		defer cancel()
+		if err := l.exec(ctx, conn, "SELECT pg_advisory_lock(?)", l.key()); err != nil {
+			if ctx.Err() == context.DeadlineExceeded {
+				return ErrLocked
+			}
+			return err
+		}
+		return nil
+	case gqbuilder.MySQL:
+		rst, err := l.builder.Raw("SELECT GET_LOCK(?, ?)", l.name, int(l.timeout/time.Second))
+		if err != nil {
+			return err
+		}
+		raw, values := rst.ToPrepared()
+		var got sql.NullInt64
+		if err := conn.QueryRowContext(ctx, raw, values...).Scan(&got); err != nil {
+			return err
+		}
+		if !got.Valid || got.Int64 != 1 {
+			return ErrLocked
+		}
+		return nil
+	default:
+		return l.lockTable(ctx, conn)
+	}
+}
+
+// lockTable insert the only row of the lock table, the insert fails while another migrator holds it. An
+// insert failing while there is no row fails for another reason, e.g. a permission, and is returned
+func (l *locker) lockTable(ctx context.Context, conn *sql.Conn) error {
+	err := l.builder.Schema().CreateTableIfNotExists(l.name, func(t *gqbuilder.TableDef) {
+		t.Integer("id").Primary()
+		t.DateTime("locked_at").NotNull()
+	}).ExecWith(ctx, conn)
+	if err != nil {
+		return err
+	}
+	deadline := time.Now().Add(l.timeout)
+	vanished := false
+	for {
+		raw, args, err := l.builder.Query(l.name).InsertPairs(
+			gqbuilder.Pair{Column: "id", Value: 1},
+			gqbuilder.Pair{Column: "locked_at", Value: time.Now().UTC().Format(timeLayout)},
+		).ToPrepared()
+		if err != nil {
+			return err
+		}
+		if _, err = conn.ExecContext(ctx, raw, args...); err == nil {
+			return nil
+		}
+		held, herr := l.held(ctx, conn)
+		if herr != nil {
+			return herr
+		}
+		if !held {
+			// the holder may have released the lock since the insert, a second miss is not a lock
+			if vanished {
+				return err
+			}
+			vanished = true
+			continue
+		}
+		vanished = false
+		if l.stale > 0 {
+			expired, derr := l.expire(ctx, conn)
+			if derr != nil {
+				return derr
+			}
+			if expired {
+				continue
+			}
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%w: %v", ErrLocked, err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+}
+
+// held reports whether the lock row exists
+func (l *locker) held(ctx context.Context, conn *sql.Conn) (bool, error) {
+	raw, args, err := l.builder.Query(l.name).Select("id").Where("id", "=", 1).ToPrepared()
+	if err != nil {
+		return false, err
+	}
+	var id int64
+	switch err := conn.QueryRowContext(ctx, raw, args...).Scan(&id); err {
+	case nil:
+		return true, nil
+	case sql.ErrNoRows:
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// expire delete the lock row when it is older than l.stale, locked_at is written in timeLayout so the
+// strings compare in time order
+func (l *locker) expire(ctx context.Context, conn *sql.Conn) (bool, error) {
+	before := time.Now().UTC().Add(-l.stale).Format(timeLayout)
+	raw, args, err := l.builder.Query(l.name).Where("id", "=", 1).Where("locked_at", "<", before).Delete().ToPrepared()
+	if err != nil {
+		return false, err
+	}
+	rst, err := conn.ExecContext(ctx, raw, args...)
+	if err != nil {
+		return false, err
+	}
+	n, err := rst.RowsAffected()
+	return n > 0, err
+}
+
+func (l *locker) unlock(ctx context.Context, conn *sql.Conn) error {
+	switch l.builder.Dialect() {
+	case gqbuilder.PostgreSQL:
+		return l.exec(ctx, conn, "SELECT pg_advisory_unlock(?)", l.key())
+	case gqbuilder.MySQL:
+		return l.exec(ctx, conn, "SELECT RELEASE_LOCK(?)", l.name)
+	default:
+		raw, args, err := l.builder.Query(l.name).Where("id", "=", 1).Delete().ToPrepared()
+		if err != nil {
+			return err
+		}
+		_, err = conn.ExecContext(ctx, raw, args...)
+		return err
+	}
+}
@@ -0,0 +1,344 @@
+// Package migrate applies versioned schema migrations with a gqbuilder.Builder. Applied versions are kept in
+// the table schema_migrations, and a lock keeps two migrators from running at the same time.
+//
+// Migrations are Go funcs registered with Register, or .sql files loaded with LoadFS:
+//
+//	20240101120000_create_users.up.sql
+//	20240101120000_create_users.down.sql
+//
+// Each migration runs in a transaction on PostgreSQL and SQLite. MySQL commits DDL implicitly, so migrations
+// run without a transaction there, as does a migration marked NoTransaction.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/paulnjiang/gqbuilder"
+)
+
+// DefaultTable is the table of applied versions
+const DefaultTable = "schema_migrations"
+
+// ErrNoMigration is returned by Down when no migration is applied
+var ErrNoMigration = errors.New("migrate: no migration to roll back")
+
+// Func changes the schema, ex is the transaction of the migration or the connection holding the lock
+type Func func(ctx context.Context, ex gqbuilder.Executor) error
+
+// Migration is a version of the schema
+type Migration struct {
+	Version int64
+	Name    string
+	Up      Func
+	Down    Func
+	// NoTransaction runs the migration outside a transaction, e.g. for CREATE INDEX CONCURRENTLY
+	NoTransaction bool
+}
+
+// Status is a migration and whether it is applied
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Migrator applies the migrations registered on it
+type Migrator struct {
+	builder     *gqbuilder.Builder
+	table       string
+	lockTimeout time.Duration
+	staleLock   time.Duration
+	migrations  map[int64]*Migration
+}
+
+// New return a Migrator on the builder's database
+func New(bdr *gqbuilder.Builder) *Migrator {
+	m := new(Migrator)
+	m.builder = bdr
+	m.table = DefaultTable
+	m.lockTimeout = time.Minute
+	m.staleLock = time.Hour
+	m.migrations = make(map[int64]*Migration)
+	return m
+}
+
+// SetTable replace the table of applied versions
+func (m *Migrator) SetTable(name string) *Migrator {
+	m.table = name
+	return m
+}
+
+// SetLockTimeout set how long to wait for another migrator to release the lock, default a minute
+func (m *Migrator) SetLockTimeout(d time.Duration) *Migrator {
+	m.lockTimeout = d
+	return m
+}
+
+// SetStaleLock set the age after which the lock row of SQLite and other dialects without a session lock is
+// taken over, default an hour. A migrator killed while holding the lock leaves the row behind, it can also
+// be removed by hand with DELETE FROM schema_migrations_lock. 0 never takes a lock over
+func (m *Migrator) SetStaleLock(d time.Duration) *Migrator {
+	m.staleLock = d
+	return m
+}
+
+// Register add a migration written in Go, down may be nil for a migration that can't be rolled back
+func (m *Migrator) Register(version int64, name string, up, down Func) error {
+	return m.Add(Migration{Version: version, Name: name, Up: up, Down: down})
+}
+
+// Add add a migration, versions must be unique
+func (m *Migrator) Add(mig Migration) error {
+	if mig.Up == nil {
+		return fmt.Errorf("migrate: migration %d has no up func", mig.Version)
+	}
+	if _, ok := m.migrations[mig.Version]; ok {
+		return fmt.Errorf("migrate: duplicate version %d", mig.Version)
+	}
+	m.migrations[mig.Version] = &mig
+	return nil
+}
+
+// Migrations return the registered migrations by version
+func (m *Migrator) Migrations() []Migration {
+	migs := make([]Migration, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		migs = append(migs, *mig)
+	}
+	sort.Slice(migs, func(i, j int) bool { return migs[i].Version < migs[j].Version })
+	return migs
+}
+
+// Up apply all pending migrations in order, it return the versions applied
+func (m *Migrator) Up(ctx context.Context) ([]int64, error) {
+	return m.UpTo(ctx, -1)
+}
+
+// UpTo apply the pending migrations up to version, a negative version applies all
+func (m *Migrator) UpTo(ctx context.Context, version int64) ([]int64, error) {
+	var done []int64
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, mig := range m.Migrations() {
+			if version >= 0 && mig.Version > version {
+				break
+			}
+			if _, ok := applied[mig.Version]; ok {
+				continue
+			}
+			if err := m.run(ctx, conn, mig, true); err != nil {
+				return err
+			}
+			done = append(done, mig.Version)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down roll back the last steps applied migrations, it return the versions rolled back. steps must be at
+// least 1
+func (m *Migrator) Down(ctx context.Context, steps int) ([]int64, error) {
+	if steps < 1 {
+		return nil, fmt.Errorf("migrate: steps must be at least 1, got %d", steps)
+	}
+	var done []int64
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		versions := make([]int64, 0, len(applied))
+		for v := range applied {
+			versions = append(versions, v)
+		}
+		sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+		if len(versions) == 0 {
+			return ErrNoMigration
+		}
+		for _, v := range versions {
+			if len(done) == steps {
+				break
+			}
+			mig, ok := m.migrations[v]
+			if !ok {
+				return fmt.Errorf("migrate: applied version %d is not registered", v)
+			}
+			if mig.Down == nil {
+				return fmt.Errorf("migrate: migration %d can't be rolled back", v)
+			}
+			if err := m.run(ctx, conn, *mig, false); err != nil {
+				return err
+			}
+			done = append(done, v)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status return the registered migrations and the applied versions which are not registered, by version. It
+// takes the lock, so it waits for a running migration
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var applied map[int64]record
+	err := m.locked(ctx, func(conn *sql.Conn) (err error) {
+		applied, err = m.applied(ctx, conn)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	var list []Status
+	for _, mig := range m.Migrations() {
+		st := Status{Version: mig.Version, Name: mig.Name}
+		if at, ok := applied[mig.Version]; ok {
+			st.Applied = true
+			st.AppliedAt = at.at
+			delete(applied, mig.Version)
+		}
+		list = append(list, st)
+	}
+	for v, rec := range applied {
+		list = append(list, Status{Version: v, Name: rec.name, Applied: true, AppliedAt: rec.at})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list, nil
+}
+
+// locked run fn on a connection holding the migration lock, the table of applied versions is created under
+// the lock so two migrators don't race creating it
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn) error) (err error) {
+	conn, err := m.builder.DB().Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	lk := newLocker(m.builder, m.table, m.lockTimeout, m.staleLock)
+	if err := lk.lock(ctx, conn); err != nil {
+		return err
+	}
+	defer func() {
+		if uerr := lk.unlock(context.Background(), conn); uerr != nil && err == nil {
+			err = uerr
+		}
+	}()
+	if err := m.ensureTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+func (m *Migrator) ensureTable(ctx context.Context, conn *sql.Conn) error {
+	return m.builder.Schema().CreateTableIfNotExists(m.table, func(t *gqbuilder.TableDef) {
+		t.BigInteger("version").Primary()
+		t.String("name", 255).NotNull()
+		t.DateTime("applied_at").NotNull()
+	}).ExecWith(ctx, conn)
+}
+
+type record struct {
+	name string
+	at   time.Time
+}
+
+// applied return the applied versions
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[int64]record, error) {
+	raw, args, err := m.builder.Query(m.table).Select("version", "name", "applied_at").OrderBy("version").ToPrepared()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := conn.QueryContext(ctx, raw, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	applied := make(map[int64]record)
+	for rows.Next() {
+		var v int64
+		var rec record
+		var at interface{}
+		if err := rows.Scan(&v, &rec.name, &at); err != nil {
+			return nil, err
+		}
+		// drivers without time parsing return the text of the column
+		switch t := at.(type) {
+		case time.Time:
+			rec.at = t
+		case string:
+			rec.at, _ = time.Parse(timeLayout, t)
+		case []byte:
+			rec.at, _ = time.Parse(timeLayout, string(t))
+		}
+		applied[v] = rec
+	}
+	return applied, rows.Err()
+}
+
+const timeLayout = "2006-01-02 15:04:05"
+
+// transactional reports whether the dialect rolls back DDL with the transaction
+func (m *Migrator) transactional(mig Migration) bool {
+	return !mig.NoTransaction && m.builder.Dialect() != gqbuilder.MySQL
+}
+
+// run apply mig, or roll it back when up is false, and record it
+func (m *Migrator) run(ctx context.Context, conn *sql.Conn, mig Migration, up bool) error {
+	fn := mig.Down
+	if up {
+		fn = mig.Up
+	}
+	var record *gqbuilder.Query
+	if up {
+		record = m.builder.Query(m.table).InsertPairs(
+			gqbuilder.Pair{Column: "version", Value: mig.Version},
+			gqbuilder.Pair{Column: "name", Value: mig.Name},
+			gqbuilder.Pair{Column: "applied_at", Value: time.Now().UTC().Format(timeLayout)},
+		)
+	} else {
+		record = m.builder.Query(m.table).Where("version", "=", mig.Version).Delete()
+	}
+	raw, args, err := record.ToPrepared()
+	if err != nil {
+		return err
+	}
+
+	if !m.transactional(mig) {
+		if err := fn(ctx, conn); err != nil {
+			return m.wrap(mig, up, err)
+		}
+		if _, err := conn.ExecContext(ctx, raw, args...); err != nil {
+			return m.wrap(mig, up, fmt.Errorf("the schema change ran but recording it in %s failed: %w", m.table, err))
+		}
+		return nil
+	}
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(ctx, tx); err != nil {
+		tx.Rollback()
+		return m.wrap(mig, up, err)
+	}
+	if _, err := tx.ExecContext(ctx, raw, args...); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (m *Migrator) wrap(mig Migration, up bool, err error) error {
+	dir := "down"
+	if up {
+		dir = "up"
+	}
+	return fmt.Errorf("migrate: %s %d_%s: %w", dir, mig.Version, mig.Name, err)
+}
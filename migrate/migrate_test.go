@@ -0,0 +1,150 @@
+package migrate
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/paulnjiang/gqbuilder"
+	"github.com/paulnjiang/gqbuilder/internal/fakedb"
+)
+
+func TestLoadFSAndUp(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/1_create_users.up.sql": {Data: []byte(`CREATE TABLE users (id INTEGER, note TEXT DEFAULT 'a;b');
+CREATE INDEX users_id ON users (id);`)},
+		"migrations/1_create_users.down.sql": {Data: []byte("DROP TABLE users;")},
+		"migrations/2_add_fn.up.sql": {Data: []byte(`-- gqb:no-transaction
+CREATE FUNCTION one() RETURNS int AS $$ SELECT 1; $$ LANGUAGE sql;`)},
+	}
+	fake, db := fakedb.New("migrate")
+	bdr := gqbuilder.NewBuilder(gqbuilder.SQLite, db)
+	m := New(bdr)
+	if err := m.LoadFS(fsys, "migrations"); err != nil {
+		t.Errorf("test load fs error: %s\n", err)
+		return
+	}
+	migs := m.Migrations()
+	if len(migs) != 2 || migs[0].Name != "create_users" || migs[0].Down == nil || migs[1].Down != nil || !migs[1].NoTransaction {
+		t.Errorf("test load fs: unexpected migrations %+v\n", migs)
+	}
+
+	done, err := m.Up(context.Background())
+	if err != nil || len(done) != 2 {
+		t.Errorf("test up: unexpected result %v %v\n", done, err)
+		return
+	}
+	expect := []string{
+		`CREATE TABLE IF NOT EXISTS "schema_migrations_lock" ("id" INTEGER PRIMARY KEY, "locked_at" DATETIME NOT NULL)`,
+		`INSERT INTO "schema_migrations_lock" ("id", "locked_at") VALUES (?, ?)`,
+		`CREATE TABLE IF NOT EXISTS "schema_migrations" ("version" INTEGER PRIMARY KEY, "name" VARCHAR(255) NOT NULL, "applied_at" DATETIME NOT NULL)`,
+		`CREATE TABLE users (id INTEGER, note TEXT DEFAULT 'a;b')`,
+		`CREATE INDEX users_id ON users (id)`,
+		`INSERT INTO "schema_migrations" ("version", "name", "applied_at") VALUES (?, ?, ?)`,
+		`CREATE FUNCTION one() RETURNS int AS $$ SELECT 1; $$ LANGUAGE sql`,
+		`INSERT INTO "schema_migrations" ("version", "name", "applied_at") VALUES (?, ?, ?)`,
+		`DELETE FROM "schema_migrations_lock" WHERE "id" = ?`,
+	}
+	if strings.Join(fake.Execs, "\n") != strings.Join(expect, "\n") {
+		t.Errorf("test up: unexpected statements\n%s\n", strings.Join(fake.Execs, "\n"))
+	}
+
+	if _, err := m.Down(context.Background(), 1); err != ErrNoMigration {
+		t.Errorf("test down: expect ErrNoMigration, got %v\n", err)
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	stmts := SplitStatements("-- header\nSELECT ';' ; /* ; */ SELECT \"a;\";\n-- trailing\n")
+	if len(stmts) != 2 || stmts[0] != "SELECT ';'" || stmts[1] != `/* ; */ SELECT "a;"` {
+		t.Errorf("test split statements: unexpected %q\n", stmts)
+	}
+}
+
+func TestDownSteps(t *testing.T) {
+	_, db := fakedb.New("migrate")
+	m := New(gqbuilder.NewBuilder(gqbuilder.SQLite, db))
+	if _, err := m.Down(context.Background(), 0); err == nil {
+		t.Errorf("test down: expect an error for 0 steps\n")
+	}
+}
+
+func TestStaleLock(t *testing.T) {
+	fake, db := fakedb.New("migrate")
+	held := true
+	fake.Err = func(query string) error {
+		if strings.HasPrefix(query, `INSERT INTO "schema_migrations_lock"`) && held {
+			return errors.New("UNIQUE constraint failed: schema_migrations_lock.id")
+		}
+		return nil
+	}
+	fake.Rows = func(query string) ([]string, [][]driver.Value) {
+		if strings.HasPrefix(query, `SELECT "id" FROM "schema_migrations_lock"`) && held {
+			return []string{"id"}, [][]driver.Value{{int64(1)}}
+		}
+		return []string{"version", "name", "applied_at"}, nil
+	}
+	fake.Affected = func(query string) int64 {
+		if strings.HasPrefix(query, `DELETE FROM "schema_migrations_lock" WHERE "id" = ? AND "locked_at" < ?`) {
+			held = false
+			return 1
+		}
+		return 0
+	}
+	m := New(gqbuilder.NewBuilder(gqbuilder.SQLite, db)).SetLockTimeout(0)
+	if _, err := m.Up(context.Background()); err != nil {
+		t.Errorf("test stale lock: expect the lock taken over, got %v\n", err)
+		return
+	}
+	expect := []string{
+		`INSERT INTO "schema_migrations_lock" ("id", "locked_at") VALUES (?, ?)`,
+		`DELETE FROM "schema_migrations_lock" WHERE "id" = ? AND "locked_at" < ?`,
+		`INSERT INTO "schema_migrations_lock" ("id", "locked_at") VALUES (?, ?)`,
+	}
+	if strings.Join(fake.Execs[1:4], "\n") != strings.Join(expect, "\n") {
+		t.Errorf("test stale lock: unexpected statements\n%s\n", strings.Join(fake.Execs, "\n"))
+	}
+
+	held = true
+	fake.Affected = nil
+	m.SetStaleLock(0)
+	if _, err := m.Up(context.Background()); !errors.Is(err, ErrLocked) {
+		t.Errorf("test stale lock: expect ErrLocked without expiry, got %v\n", err)
+	}
+}
+
+func TestLockError(t *testing.T) {
+	fake, db := fakedb.New("migrate")
+	fake.Err = func(query string) error {
+		if strings.HasPrefix(query, `INSERT INTO "schema_migrations_lock"`) {
+			return errors.New("attempt to write a readonly database")
+		}
+		return nil
+	}
+	m := New(gqbuilder.NewBuilder(gqbuilder.SQLite, db))
+	start := time.Now()
+	if _, err := m.Up(context.Background()); err == nil || errors.Is(err, ErrLocked) || time.Since(start) > time.Second {
+		t.Errorf("test lock error: expect the insert error at once, got %v after %s\n", err, time.Since(start))
+	}
+}
+
+func TestUnrecordedMigration(t *testing.T) {
+	fake, db := fakedb.New("migrate")
+	fake.Err = func(query string) error {
+		if strings.HasPrefix(query, `INSERT INTO "schema_migrations"`) {
+			return errors.New("disk I/O error")
+		}
+		return nil
+	}
+	m := New(gqbuilder.NewBuilder(gqbuilder.SQLite, db))
+	m.Add(Migration{Version: 3, Name: "add_index", NoTransaction: true,
+		Up: func(ctx context.Context, ex gqbuilder.Executor) error { return nil }})
+	_, err := m.Up(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "up 3_add_index: the schema change ran") {
+		t.Errorf("test unrecorded migration: unexpected error %v\n", err)
+	}
+}
@@ -0,0 +1,163 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/paulnjiang/gqbuilder"
+)
+
+// noTxDirective in the first lines of an up file runs the migration without a transaction
+const noTxDirective = "-- gqb:no-transaction"
+
+// LoadFS register the .sql migrations of dir in fsys. Files are named "<version>_<name>.up.sql" and
+// "<version>_<name>.down.sql", the down file is optional. A file may hold several statements separated by ";"
+func (m *Migrator) LoadFS(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return err
+	}
+	found := make(map[int64]*Migration)
+	for _, ent := range entries {
+		if ent.IsDir() || !strings.HasSuffix(ent.Name(), ".sql") {
+			continue
+		}
+		base := strings.TrimSuffix(ent.Name(), ".sql")
+		up := strings.HasSuffix(base, ".up")
+		if !up && !strings.HasSuffix(base, ".down") {
+			return fmt.Errorf("migrate: %s is neither an .up.sql nor a .down.sql file", ent.Name())
+		}
+		base = strings.TrimSuffix(strings.TrimSuffix(base, ".up"), ".down")
+		sep := strings.Index(base, "_")
+		if sep < 0 {
+			sep = len(base)
+		}
+		version, err := strconv.ParseInt(base[:sep], 10, 64)
+		if err != nil {
+			return fmt.Errorf("migrate: %s doesn't start with a version number", ent.Name())
+		}
+		content, err := fs.ReadFile(fsys, path.Join(dir, ent.Name()))
+		if err != nil {
+			return err
+		}
+
+		mig, ok := found[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: strings.TrimPrefix(base[sep:], "_")}
+			found[version] = mig
+		}
+		fn := sqlFunc(SplitStatements(string(content)))
+		if up {
+			mig.Up = fn
+			mig.NoTransaction = hasNoTxDirective(string(content))
+		} else {
+			mig.Down = fn
+		}
+	}
+	for _, mig := range found {
+		if err := m.Add(*mig); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func hasNoTxDirective(content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == noTxDirective {
+			return true
+		}
+		if line != "" && !strings.HasPrefix(line, "--") {
+			return false
+		}
+	}
+	return false
+}
+
+// sqlFunc return a Func executing stmts one by one
+func sqlFunc(stmts []string) Func {
+	return func(ctx context.Context, ex gqbuilder.Executor) error {
+		for _, stmt := range stmts {
+			if _, err := ex.ExecContext(ctx, stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// SplitStatements split a sql script by ";" and drop the comment lines before each statement. Semicolons in
+// literals, quoted identifiers, comments and PostgreSQL dollar quoted bodies don't end a statement. Empty
+// statements are dropped
+func SplitStatements(script string) []string {
+	var stmts []string
+	start := 0
+	n := len(script)
+	add := func(end int) {
+		if s := trimLeadingComments(script[start:end]); s != "" {
+			stmts = append(stmts, s)
+		}
+	}
+	for i := 0; i < n; i++ {
+		ch := script[i]
+		switch {
+		case ch == '\'' || ch == '"' || ch == '`':
+			for i++; i < n && script[i] != ch; i++ {
+			}
+		case ch == '-' && i+1 < n && script[i+1] == '-':
+			for i += 2; i < n && script[i] != '\n'; i++ {
+			}
+		case ch == '/' && i+1 < n && script[i+1] == '*':
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				i = n
+				break
+			}
+			i += end + 3
+		case ch == '$':
+			// $$ or $tag$ opens a body closed by the same tag
+			j := i + 1
+			for j < n && (script[j] == '_' || isAlnum(script[j])) {
+				j++
+			}
+			if j < n && script[j] == '$' {
+				tag := script[i : j+1]
+				end := strings.Index(script[j+1:], tag)
+				if end < 0 {
+					i = n
+					break
+				}
+				i = j + end + len(tag)
+			}
+		case ch == ';':
+			add(i)
+			start = i + 1
+		}
+	}
+	if start < n {
+		add(n)
+	}
+	return stmts
+}
+
+func isAlnum(ch byte) bool {
+	return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9')
+}
+
+// trimLeadingComments remove spaces and the "--" comment lines before a statement
+func trimLeadingComments(s string) string {
+	s = strings.TrimSpace(s)
+	for strings.HasPrefix(s, "--") {
+		end := strings.Index(s, "\n")
+		if end < 0 {
+			return ""
+		}
+		s = strings.TrimSpace(s[end+1:])
+	}
+	return s
+}
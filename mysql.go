@@ -7,9 +7,11 @@ type mysqlCompiler struct {
 
 func newMySQLCompiler() *mysqlCompiler {
 	c := new(mysqlCompiler)
+	c.engine = MySQL
 	c.paramsPattern = PlaceHolder
 	c.symbolPrefix = "?"
 	c.leftIdentifier = "`"
 	c.righIdentifier = "`"
+	c.conditions = standardConditions{}
 	return c
 }
@@ -0,0 +1,148 @@
+package gqbuilder
+
+/*
+	normalize sql statements, so the statements which differ only in values read the same
+*/
+
+import (
+	"hash/fnv"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	reValueList = regexp.MustCompile(`\(\?(, \?)*\)`)
+	reTupleList = regexp.MustCompile(`\(\?\)(, \(\?\))+`)
+)
+
+// normalizeSQL replace literals, numbers and bind variables of sqlText with "?", drop comments, collapse
+// spaces and reduce lists of values like "IN (?, ?, ?)" and "VALUES (?, ?), (?, ?)" to "(?)"
+func normalizeSQL(sqlText string) string {
+	var out strings.Builder
+	out.Grow(len(sqlText))
+	space := false
+	emit := func(s string) {
+		if space && out.Len() > 0 {
+			out.WriteByte(' ')
+		}
+		space = false
+		out.WriteString(s)
+	}
+	n := len(sqlText)
+	for i := 0; i < n; i++ {
+		ch := sqlText[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			space = true
+		case ch == '-' && i+1 < n && sqlText[i+1] == '-':
+			for i += 2; i < n && sqlText[i] != '\n'; i++ {
+			}
+			space = true
+		case ch == '/' && i+1 < n && sqlText[i+1] == '*':
+			end := strings.Index(sqlText[i+2:], "*/")
+			if end < 0 {
+				i = n
+			} else {
+				i += end + 3
+			}
+			space = true
+		case ch == '\'':
+			for i++; i < n; i++ {
+				if sqlText[i] == '\'' {
+					if i+1 < n && sqlText[i+1] == '\'' {
+						i++
+						continue
+					}
+					break
+				}
+			}
+			emit("?")
+		case ch == '"' || ch == '`':
+			j := i + 1
+			for j < n && sqlText[j] != ch {
+				j++
+			}
+			if j >= n {
+				j = n - 1
+			}
+			emit(sqlText[i : j+1])
+			i = j
+		case ch == '?':
+			emit("?")
+		case ch == '$' && i+1 < n && sqlText[i+1] >= '0' && sqlText[i+1] <= '9':
+			for i++; i+1 < n && sqlText[i+1] >= '0' && sqlText[i+1] <= '9'; i++ {
+			}
+			emit("?")
+		case ch == ':' && i+1 < n && sqlText[i+1] == ':':
+			emit("::")
+			i++
+		case ch == '@' && i+1 < n && sqlText[i+1] == '@':
+			j := i + 2
+			for j < n && isNameByte(sqlText[j]) {
+				j++
+			}
+			emit(sqlText[i:j])
+			i = j - 1
+		case (ch == ':' || ch == '@') && i+1 < n && (sqlText[i+1] == '_' || isLetter(sqlText[i+1])):
+			for i++; i+1 < n && isNameByte(sqlText[i+1]); i++ {
+			}
+			emit("?")
+		case ch >= '0' && ch <= '9':
+			for i+1 < n && (isNameByte(sqlText[i+1]) || sqlText[i+1] == '.') {
+				i++
+			}
+			emit("?")
+		case isNameByte(ch):
+			j := i + 1
+			for j < n && (isNameByte(sqlText[j]) || sqlText[j] == '$') {
+				j++
+			}
+			emit(sqlText[i:j])
+			i = j - 1
+		default:
+			if ch == ',' || ch == ')' {
+				// "a , b" and "( a )" read as "a, b" and "(a)"
+				space = false
+			}
+			emit(string(ch))
+			if ch == '(' {
+				space = false
+				for i+1 < n && sqlText[i+1] == ' ' {
+					i++
+				}
+			}
+		}
+	}
+	s := reValueList.ReplaceAllString(out.String(), "(?)")
+	return reTupleList.ReplaceAllString(s, "(?)")
+}
+
+// fingerprint return the hash of a normalized statement as 16 hex digits
+func fingerprint(normalized string) string {
+	h := fnv.New64a()
+	h.Write([]byte(normalized))
+	s := strconv.FormatUint(h.Sum64(), 16)
+	return strings.Repeat("0", 16-len(s)) + s
+}
+
+// Normalized return the sql with literals and bind variables replaced by "?", comments dropped and lists of
+// values like "IN (?, ?, ?)" reduced to "(?)", so the statements of a query shape read the same
+func (s *SQLResult) Normalized() string {
+	return normalizeSQL(s.rawSQL)
+}
+
+// Fingerprint return the hash of Normalized() as 16 hex digits
+func (s *SQLResult) Fingerprint() string {
+	return fingerprint(s.Normalized())
+}
+
+// Fingerprint compile the query and return the hash of its normalized sql. Queries which differ only in
+// values, or in the number of values of an IN list, have the same fingerprint
+func (q *Query) Fingerprint() (string, error) {
+	rst, err := q.Compile()
+	if err != nil {
+		return "", err
+	}
+	return rst.Fingerprint(), nil
+}
@@ -0,0 +1,47 @@
+package gqbuilder
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestNormalizeSQL(t *testing.T) {
+	cases := map[string]string{
+		"SELECT `id` FROM `user` WHERE `id` IN ( ?, ?, ? ) AND name = 'bo''b' -- note\n AND x > 10.5": "SELECT `id` FROM `user` WHERE `id` IN (?) AND name = ? AND x > ?",
+		`INSERT INTO "t" ("a", "b") VALUES ($1, $2), ($3, $4)`:                                        `INSERT INTO "t" ("a", "b") VALUES (?)`,
+		"SELECT a::int, @@version, :name FROM t1 WHERE c = @p":                                        "SELECT a::int, @@version, ? FROM t1 WHERE c = ?",
+	}
+	for in, expect := range cases {
+		if got := normalizeSQL(in); got != expect {
+			t.Errorf("test normalize sql: expect %s, got %s\n", expect, got)
+		}
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	var con *sql.DB
+	bdr := NewBuilder(PostgreSQL, con)
+	a, err := bdr.Query("user").Select("id").WhereIn("id", 1, 2).Where("name", "=", "bob").Fingerprint()
+	if err != nil {
+		t.Errorf("test fingerprint error: %s\n", err)
+		return
+	}
+	b, _ := bdr.Query("user").Select("id").WhereIn("id", 3, 4, 5, 6).Where("name", "=", "alice").Fingerprint()
+	c, _ := bdr.Query("user").Select("id").WhereIn("id", 1, 2).Where("age", "=", 3).Fingerprint()
+	if a != b || a == c || len(a) != 16 {
+		t.Errorf("test fingerprint: unexpected %s %s %s\n", a, b, c)
+	}
+
+	item := map[string]interface{}{"name": "bob", "age": 3, "email": "b@x", "role": "admin", "team": 7}
+	rst, _ := bdr.Query("user").Where("id", "=", 1).Update(item).Compile()
+	for i := 0; i < 10; i++ {
+		again, _ := bdr.Query("user").Where("id", "=", 2).Update(item).Compile()
+		if again.Normalized() != rst.Normalized() || again.Fingerprint() != rst.Fingerprint() {
+			t.Errorf("test fingerprint: update isn't deterministic %s\n", again.Normalized())
+		}
+	}
+	expect := `UPDATE "user" SET "age"=?, "email"=?, "name"=?, "role"=?, "team"=? WHERE "id" = ?`
+	if rst.Normalized() != expect {
+		t.Errorf("test fingerprint: unexpected normalized sql %s\n", rst.Normalized())
+	}
+}
@@ -6,9 +6,11 @@ type pgCompiler struct {
 
 func newPostgreSQLCompiler() *pgCompiler {
 	c := new(pgCompiler)
+	c.engine = PostgreSQL
 	c.paramsPattern = Ordinal
 	c.symbolPrefix = "$"
 	c.leftIdentifier = "\""
 	c.righIdentifier = "\""
+	c.conditions = standardConditions{}
 	return c
 }
@@ -0,0 +1,109 @@
+package gqbuilder
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// PreparedQuery is a compiled query and its prepared statement, every call only sends new argument values
+type PreparedQuery struct {
+	builder *Builder
+	info    stmtInfo
+	result  *SQLResult
+	stmt    *sql.Stmt
+}
+
+// Prepare compile the query and prepare it on the builder's database
+func (q *Query) Prepare(ctx context.Context) (*PreparedQuery, error) {
+	rst, err := q.Compile()
+	if err != nil {
+		return nil, err
+	}
+	stmt, err := q.builder.pool.PrepareContext(ctx, rst.rawSQL)
+	if err != nil {
+		return nil, err
+	}
+	p := new(PreparedQuery)
+	p.builder = q.builder
+	p.info = q.info()
+	p.result = rst
+	p.stmt = stmt
+	return p, nil
+}
+
+// SQL return the prepared sql statement
+func (p *PreparedQuery) SQL() string {
+	return p.result.rawSQL
+}
+
+// args return the values sent with the statement. Without args the values compiled into the query are used,
+// a single Params gives the values of named parameters, otherwise args replace the compiled values one by one
+func (p *PreparedQuery) args(args []interface{}) ([]interface{}, error) {
+	if len(args) == 0 {
+		return p.result.Args()
+	}
+	if params, ok := args[0].(Params); ok && len(args) == 1 {
+		return p.result.Args(params)
+	}
+	if len(args) != p.result.args.Len() {
+		return nil, fmt.Errorf("statement takes %d arguments, got %d", p.result.args.Len(), len(args))
+	}
+	values := make([]interface{}, 0, len(args))
+	for i, v := range args {
+		if nam, ok := p.result.args.GetByIndex(i).(sql.NamedArg); ok {
+			v = sql.Named(nam.Name, v)
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// Do execute the statement with Stmt.QueryRowContext()
+func (p *PreparedQuery) Do(ctx context.Context, args ...interface{}) (*sql.Row, error) {
+	values, err := p.args(args)
+	if err != nil {
+		return nil, err
+	}
+	var row *sql.Row
+	err = p.builder.observe(ctx, opQueryRow, p.info, p.result.rawSQL, values, func(ctx context.Context) (int64, error) {
+		row = p.stmt.QueryRowContext(ctx, values...)
+		return -1, nil
+	})
+	return row, err
+}
+
+// Get execute the statement with Stmt.QueryContext()
+func (p *PreparedQuery) Get(ctx context.Context, args ...interface{}) (*sql.Rows, error) {
+	values, err := p.args(args)
+	if err != nil {
+		return nil, err
+	}
+	var rows *sql.Rows
+	err = p.builder.observe(ctx, opQuery, p.info, p.result.rawSQL, values, func(ctx context.Context) (int64, error) {
+		var err error
+		rows, err = p.stmt.QueryContext(ctx, values...)
+		return -1, err
+	})
+	return rows, err
+}
+
+// Exec execute the statement with Stmt.ExecContext()
+func (p *PreparedQuery) Exec(ctx context.Context, args ...interface{}) (sql.Result, error) {
+	values, err := p.args(args)
+	if err != nil {
+		return nil, err
+	}
+	var res sql.Result
+	err = p.builder.observe(ctx, opExec, p.info, p.result.rawSQL, values, func(ctx context.Context) (int64, error) {
+		var err error
+		res, err = p.stmt.ExecContext(ctx, values...)
+		return rowsAffected(res, err), err
+	})
+	return res, err
+}
+
+// Close close the prepared statement
+func (p *PreparedQuery) Close() error {
+	return p.stmt.Close()
+}
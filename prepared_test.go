@@ -0,0 +1,75 @@
+package gqbuilder
+
+import (
+	"context"
+	"testing"
+
+	"github.com/paulnjiang/gqbuilder/internal/fakedb"
+)
+
+func TestPreparedQuery(t *testing.T) {
+	ctx := context.Background()
+	fake, db := fakedb.New("prepared")
+	bdr := NewBuilder(PostgreSQL, db)
+	q := bdr.Query("user").Select("id")
+	q.Where("age", ">", q.Param("min_age")).Where("status", "=", 1)
+	p, err := q.Prepare(ctx)
+	if err != nil {
+		t.Errorf("test prepared query error: %s\n", err)
+		return
+	}
+	defer p.Close()
+	if p.SQL() != `SELECT "id" FROM "user" WHERE "age" > $1 AND "status" = $2` {
+		t.Errorf("test prepared query: unexpected sql %s\n", p.SQL())
+	}
+	for i := 0; i < 3; i++ {
+		rows, err := p.Get(ctx, Params{"min_age": 20 + i})
+		if err != nil {
+			t.Errorf("test prepared query error: %s\n", err)
+			return
+		}
+		rows.Close()
+	}
+	if _, err = p.Get(ctx, 1); err == nil {
+		t.Errorf("test prepared query: expect an error for wrong number of arguments\n")
+	}
+	if _, err = p.Get(ctx, 30, 2); err != nil {
+		t.Errorf("test prepared query error: %s\n", err)
+	}
+	if fake.Prepares != 1 || len(fake.Queries) != 4 {
+		t.Errorf("test prepared query: expect 1 prepare and 4 queries, got %d and %d\n", fake.Prepares, len(fake.Queries))
+	}
+}
+
+func TestStmtCache(t *testing.T) {
+	ctx := context.Background()
+	fake, db := fakedb.New("cache")
+	bdr := NewBuilder(SQLite, db).EnableStmtCache(2)
+	queries := []*Query{
+		bdr.Query("a").Where("id", "=", 1),
+		bdr.Query("b").Where("id", "=", 1),
+		bdr.Query("a").Where("id", "=", 2),
+		bdr.Query("c").Where("id", "=", 1),
+		bdr.Query("a").Where("id", "=", 3),
+		bdr.Query("b").Where("id", "=", 4),
+	}
+	for _, q := range queries {
+		rows, err := q.Get(ctx)
+		if err != nil {
+			t.Errorf("test stmt cache error: %s\n", err)
+			return
+		}
+		rows.Close()
+	}
+	stats := bdr.StmtCacheStats()
+	if stats.Hits != 2 || stats.Misses != 4 || stats.Evictions != 2 || stats.Size != 2 {
+		t.Errorf("test stmt cache: unexpected stats %+v\n", stats)
+	}
+	if fake.Prepares != 4 || fake.Closes != 2 {
+		t.Errorf("test stmt cache: expect 4 prepares and 2 closes, got %d and %d\n", fake.Prepares, fake.Closes)
+	}
+	bdr.EnableStmtCache(0)
+	if fake.Closes != 4 {
+		t.Errorf("test stmt cache: expect all statements closed, got %d\n", fake.Closes)
+	}
+}
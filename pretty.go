@@ -0,0 +1,281 @@
+package gqbuilder
+
+/*
+	pretty print sql: a line per clause, indented joins, conditions and sub queries
+*/
+
+import (
+	"strings"
+)
+
+// KeywordCase is how Pretty writes keywords
+type KeywordCase int
+
+// Keyword cases of PrettyOptions
+const (
+	KeepKeywords KeywordCase = iota
+	UpperKeywords
+	LowerKeywords
+)
+
+// PrettyOptions configure Pretty and FormatSQL, the zero value keeps keywords as written and indents with
+// two spaces
+type PrettyOptions struct {
+	Indent   string
+	Keywords KeywordCase
+}
+
+// Pretty return the sql of the result formatted by FormatSQL
+func (s *SQLResult) Pretty(opts PrettyOptions) string {
+	return FormatSQL(s.rawSQL, opts)
+}
+
+// keywords changed by KeywordCase
+var sqlKeywords = map[string]bool{}
+
+func init() {
+	for _, kw := range strings.Fields(`SELECT DISTINCT FROM WHERE AND OR NOT IN IS NULL LIKE ILIKE BETWEEN EXISTS
+		JOIN LEFT RIGHT INNER OUTER FULL CROSS NATURAL ON USING AS GROUP BY ORDER HAVING LIMIT OFFSET FETCH NEXT
+		ROWS ROW ONLY INSERT INTO VALUES UPDATE SET DELETE TRUNCATE TABLE UNION INTERSECT EXCEPT ALL ANY SOME ASC
+		DESC NULLS FIRST LAST CASE WHEN THEN ELSE END WITH RECURSIVE RETURNING ROLLUP CUBE GROUPING SETS TRUE FALSE
+		CREATE ALTER DROP INDEX PRIMARY KEY FOREIGN REFERENCES DEFAULT UNIQUE CONSTRAINT IF COLUMN ADD RENAME TO
+		FOR SHARE OVER PARTITION CAST INTERVAL CONFLICT DO NOTHING LATERAL WINDOW`) {
+		sqlKeywords[kw] = true
+	}
+}
+
+// clauses starting a line at the level of their statement
+var clauseWords = map[string]bool{
+	"SELECT": true, "FROM": true, "WHERE": true, "GROUP": true, "HAVING": true, "ORDER": true, "LIMIT": true,
+	"OFFSET": true, "FETCH": true, "UNION": true, "INTERSECT": true, "EXCEPT": true, "INSERT": true,
+	"VALUES": true, "UPDATE": true, "SET": true, "DELETE": true, "RETURNING": true, "WITH": true, "WINDOW": true,
+}
+
+// words after which a clause word is part of the clause before it, e.g. "ON DELETE", "FOR UPDATE"
+var inlineAfter = map[string]bool{
+	"ON": true, "DO": true, "FOR": true, "UPDATE": true, "GROUPING": true, "DISTINCT": true,
+}
+
+// words starting a join, they start a line indented under FROM
+var joinWords = map[string]bool{
+	"JOIN": true, "LEFT": true, "RIGHT": true, "INNER": true, "FULL": true, "CROSS": true, "NATURAL": true,
+}
+
+type sqlToken struct {
+	text  string
+	word  bool // unquoted word, may be a keyword
+	space bool // spaces precede the token in the source
+	line  bool // a "--" comment, the line ends after it
+}
+
+// tokenizeSQL split sqlText into words, quoted names, literals, comments and punctuation
+func tokenizeSQL(sqlText string) []sqlToken {
+	var toks []sqlToken
+	space := false
+	n := len(sqlText)
+	for i := 0; i < n; {
+		ch := sqlText[i]
+		start := i
+		tok := sqlToken{space: space}
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			space = true
+			i++
+			continue
+		case ch == '-' && i+1 < n && sqlText[i+1] == '-':
+			for i < n && sqlText[i] != '\n' {
+				i++
+			}
+			tok.line = true
+		case ch == '/' && i+1 < n && sqlText[i+1] == '*':
+			end := strings.Index(sqlText[i+2:], "*/")
+			if end < 0 {
+				i = n
+			} else {
+				i += end + 4
+			}
+		case ch == '\'' || ch == '"' || ch == '`':
+			for i++; i < n; i++ {
+				if sqlText[i] == ch {
+					if i+1 < n && sqlText[i+1] == ch {
+						i++
+						continue
+					}
+					break
+				}
+			}
+			i++
+		case isNameByte(ch) || ch == '$' || ch == '@' || (ch == ':' && i+1 < n && isNameByte(sqlText[i+1])):
+			i++
+			for i < n && (isNameByte(sqlText[i]) || sqlText[i] == '$') {
+				i++
+			}
+			tok.word = isNameByte(ch) && !(ch >= '0' && ch <= '9')
+		case ch == ':' && i+1 < n && sqlText[i+1] == ':':
+			i += 2
+		case strings.IndexByte("<>!=|", ch) >= 0:
+			for i++; i < n && strings.IndexByte("<>=|", sqlText[i]) >= 0; i++ {
+			}
+		default:
+			i++
+		}
+		if i > n {
+			i = n
+		}
+		tok.text = sqlText[start:i]
+		toks = append(toks, tok)
+		space = false
+	}
+	return toks
+}
+
+// FormatSQL write every clause of sqlText on its own line. Joins and the AND and OR of WHERE, HAVING and ON
+// are indented under their clause, sub queries and CTE bodies are indented inside their parentheses.
+// Statements are separated by a blank line
+func FormatSQL(sqlText string, opts PrettyOptions) string {
+	indent := opts.Indent
+	if indent == "" {
+		indent = "  "
+	}
+	toks := tokenizeSQL(sqlText)
+
+	var out strings.Builder
+	type paren struct {
+		sub         bool
+		join        bool
+		between     bool // the AND of a BETWEEN before the parenthesis is still to come
+		level, line int  // indentation before the parenthesis and of its line
+	}
+	var stack []paren
+	level := 0   // indentation of the clauses of the statement
+	indents := 0 // indentation of the current line
+	caseDepth := 0
+	between := false
+	join := false // the AND and OR are of a join condition
+	lineStart := true
+	newline := func(extra int) {
+		if out.Len() > 0 && !lineStart {
+			out.WriteByte('\n')
+		}
+		indents = level + extra
+		lineStart = true
+	}
+	write := func(tok sqlToken, text string) {
+		if lineStart {
+			out.WriteString(strings.Repeat(indent, indents))
+		} else if tok.space {
+			out.WriteByte(' ')
+		}
+		out.WriteString(text)
+		lineStart = false
+	}
+	nextWord := func(i int) string {
+		if i+1 < len(toks) && toks[i+1].word {
+			return strings.ToUpper(toks[i+1].text)
+		}
+		return ""
+	}
+	statementLevel := func() bool {
+		return (len(stack) == 0 || stack[len(stack)-1].sub) && caseDepth == 0
+	}
+
+	prev := ""
+	for i, tok := range toks {
+		text := tok.text
+		upper := ""
+		if tok.word {
+			upper = strings.ToUpper(text)
+			if sqlKeywords[upper] {
+				switch opts.Keywords {
+				case UpperKeywords:
+					text = upper
+				case LowerKeywords:
+					text = strings.ToLower(text)
+				}
+			}
+		}
+
+		switch {
+		case tok.word && upper == "CASE":
+			caseDepth++
+		case tok.word && upper == "END" && caseDepth > 0:
+			caseDepth--
+		case tok.word && upper == "BETWEEN" && statementLevel():
+			between = true
+		}
+
+		switch {
+		case text == "(":
+			sub := nextWord(i) == "SELECT" || nextWord(i) == "WITH"
+			stack = append(stack, paren{sub, join, between, level, indents})
+			between = false
+			write(tok, text)
+			if sub {
+				level = indents + 1
+				newline(0)
+			}
+		case text == ")":
+			if len(stack) > 0 {
+				p := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				between = p.between
+				if p.sub {
+					level, join = p.level, p.join
+					newline(p.line - level)
+					tok.space = false
+				}
+			}
+			write(tok, text)
+		case text == ";":
+			tok.space = false
+			write(tok, text)
+			if i+1 < len(toks) {
+				out.WriteString("\n\n")
+				lineStart = true
+			}
+			stack, level, caseDepth, join, between = nil, 0, 0, false, false
+		case tok.word && statementLevel() && (upper == "ON" && nextWord(i) == "CONFLICT" ||
+			upper == "FOR" && (nextWord(i) == "UPDATE" || nextWord(i) == "SHARE" || nextWord(i) == "NO")):
+			newline(0)
+			write(tok, text)
+			join = false
+		case tok.word && statementLevel() && clauseWords[upper] && !inlineAfter[prev] &&
+			(upper != "GROUP" && upper != "ORDER" || nextWord(i) == "BY") && !(upper == "WITH" && nextWord(i) == "ROLLUP"):
+			newline(0)
+			write(tok, text)
+			join = false
+		case tok.word && statementLevel() && joinWords[upper] && !joinWords[prev] && prev != "OUTER" &&
+			(i+1 >= len(toks) || toks[i+1].text != "("):
+			newline(1)
+			write(tok, text)
+			join = true
+		case tok.word && statementLevel() && (upper == "AND" || upper == "OR"):
+			if upper == "AND" && between {
+				between = false
+				write(tok, text)
+				break
+			}
+			if join {
+				newline(2)
+			} else {
+				newline(1)
+			}
+			write(tok, text)
+		default:
+			write(tok, text)
+		}
+		if tok.line {
+			newline(0)
+		}
+		if tok.word {
+			prev = upper
+		} else {
+			prev = text
+		}
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.Join(lines, "\n")
+}
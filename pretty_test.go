@@ -0,0 +1,71 @@
+package gqbuilder
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestFormatSQL(t *testing.T) {
+	in := "WITH t AS (SELECT id FROM a WHERE x = 1) SELECT a.id, count(*) AS n FROM a LEFT JOIN b ON a.id = b.aid " +
+		"AND b.y > 2 WHERE a.x BETWEEN ? AND ? AND EXISTS (SELECT 1 FROM c WHERE c.id = a.id) OR a.z IN (1, 2) " +
+		"GROUP BY a.id ORDER BY n DESC LIMIT 10"
+	expect := `WITH t AS (
+  SELECT id
+  FROM a
+  WHERE x = 1
+)
+SELECT a.id, count(*) AS n
+FROM a
+  LEFT JOIN b ON a.id = b.aid
+    AND b.y > 2
+WHERE a.x BETWEEN ? AND ?
+  AND EXISTS (
+    SELECT 1
+    FROM c
+    WHERE c.id = a.id
+  )
+  OR a.z IN (1, 2)
+GROUP BY a.id
+ORDER BY n DESC
+LIMIT 10`
+	if got := FormatSQL(in, PrettyOptions{}); got != expect {
+		t.Errorf("test format sql: expect\n%s\ngot\n%s\n", expect, got)
+	}
+
+	cases := map[string]string{
+		"select a, case when x and y then 1 end from t -- note\n where a = 'and' union all select 1; select 2": "select a, case when x and y then 1 end\nfrom t -- note\nwhere a = 'and'\nunion all\nselect 1;\n\nselect 2",
+		"SELECT row_number() OVER (PARTITION BY a ORDER BY b) FROM t FOR UPDATE":                               "select row_number() over (partition by a order by b)\nfrom t\nfor update",
+		"INSERT INTO t (a) VALUES (1) ON CONFLICT (a) DO UPDATE SET a = excluded.a":                            "insert into t (a)\nvalues (1)\non conflict (a) do update set a = excluded.a",
+		"SELECT a FROM t WHERE (b BETWEEN 1 AND 2) AND c = 1 AND d = 2":                                        "select a\nfrom t\nwhere (b between 1 and 2)\n  and c = 1\n  and d = 2",
+		"SELECT a FROM t WHERE CASE WHEN b BETWEEN 1 AND 2 THEN 1 END = 1 AND c = 1":                           "select a\nfrom t\nwhere case when b between 1 and 2 then 1 end = 1\n  and c = 1",
+	}
+	for in, expect := range cases {
+		if got := FormatSQL(in, PrettyOptions{Keywords: LowerKeywords}); got != expect {
+			t.Errorf("test format sql: expect\n%s\ngot\n%s\n", expect, got)
+		}
+	}
+}
+
+func TestPretty(t *testing.T) {
+	var con *sql.DB
+	bdr := NewBuilder(PostgreSQL, con)
+	sub := bdr.Query("orders").Select("id").Where("user_id", "=", 7)
+	rst, err := bdr.Query("user").Select("id").Join("team", "team.id", "=", "user.team_id").
+		WhereExists(sub).Where("age", ">", 3).Compile()
+	if err != nil {
+		t.Errorf("test pretty error: %s\n", err)
+		return
+	}
+	expect := `select "id"
+from "user"
+  inner join "team" on "team"."id" = "user"."team_id"
+where exists (
+  select "id"
+  from "orders"
+  where "user_id" = $1
+)
+  and "age" > $2`
+	if got := rst.Pretty(PrettyOptions{Keywords: LowerKeywords}); got != expect {
+		t.Errorf("test pretty: expect\n%s\ngot\n%s\n", expect, got)
+	}
+}
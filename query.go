@@ -1,7 +1,10 @@
 package gqbuilder
 
 import (
+	"context"
+	"errors"
 	"regexp"
+	"sort"
 	"strings"
 	"database/sql"
 )
@@ -14,17 +17,56 @@ type Query struct {
 	flagOr     bool
 	flagNot    bool
 	isDistinct bool
+	keyColumn  string
+
+	allowFullTable bool
+	immutable      bool
+	onPrimary      bool
+}
+
+// Pair is a column and the value written to it by InsertPairs and UpdatePairs
+type Pair struct {
+	Column string
+	Value  interface{}
 }
 
 func newQuery(bd *Builder) *Query {
 	q := new(Query)
 	q.builder = bd
 	q.method = selectMethod
+	q.keyColumn = "id"
 	return q
 }
 
-func (q *Query) clone() *Query {
+// Clone return a deep copy of the query, elements and sub queries are copied so changing the clone never
+// changes the original. A pending Or() or Not() is not copied
+func (q *Query) Clone() *Query {
 	qq := *q
+	qq.flagOr = false
+	qq.flagNot = false
+	qq.elements = make([]element, 0, len(q.elements))
+	for _, elm := range q.elements {
+		qq.elements = append(qq.elements, cloneElement(elm))
+	}
+	return &qq
+}
+
+// Immutable return a copy of the query in copy-on-write mode, every builder method of it returns a new
+// Query and leaves the receiver unchanged, so it can be shared by goroutines as a base query
+func (q *Query) Immutable() *Query {
+	qq := q.Clone()
+	qq.immutable = true
+	return qq
+}
+
+// derive return the query that a builder method should change, it is the receiver itself unless the
+// query is immutable. Elements are values and never changed in place, a new slice is enough
+func (q *Query) derive() *Query {
+	if !q.immutable {
+		return q
+	}
+	qq := *q
+	qq.elements = append(make([]element, 0, len(q.elements)+1), q.elements...)
 	return &qq
 }
 
@@ -98,6 +140,29 @@ func (q *Query) getElements(name string) ([]element, int) {
 	return elms, len(elms)
 }
 
+func sortedPairs(item map[string]interface{}) ([]string, []interface{}) {
+	columns := make([]string, 0, len(item))
+	for k := range item {
+		columns = append(columns, k)
+	}
+	sort.Strings(columns)
+	values := make([]interface{}, 0, len(item))
+	for _, k := range columns {
+		values = append(values, item[k])
+	}
+	return columns, values
+}
+
+func splitPairs(pairs []Pair) ([]string, []interface{}) {
+	columns := make([]string, 0, len(pairs))
+	values := make([]interface{}, 0, len(pairs))
+	for _, p := range pairs {
+		columns = append(columns, p.Column)
+		values = append(values, p.Value)
+	}
+	return columns, values
+}
+
 func (q *Query) splitAlias(mixture string) (name, alias string) {
 	re, _ := regexp.Compile(`\s+(as|AS|As|aS)\s+`)
 	ns := re.Split(strings.TrimSpace(mixture), 2)
@@ -107,20 +172,24 @@ func (q *Query) splitAlias(mixture string) (name, alias string) {
 	return ns[0], ""
 }
 
-// Not is NOT operator
+// Not is NOT operator, it negates the next LIKE, BETWEEN, IN, NULL, boolean, EXISTS or Condition. Comparisons
+// and raw conditions ignore it
 func (q *Query) Not() *Query {
+	q = q.derive()
 	q.flagNot = true
 	return q
 }
 
 // Or is OR operator
 func (q *Query) Or() *Query {
+	q = q.derive()
 	q.flagOr = true
 	return q
 }
 
 // From is FROM clause
 func (q *Query) From(tables ...string) *Query {
+	q = q.derive()
 	for _, tab := range tables {
 		var cls fromClause
 		nam, alias := q.splitAlias(tab)
@@ -136,6 +205,7 @@ func (q *Query) From(tables ...string) *Query {
 
 // Select add a SELECT clause to query statement
 func (q *Query) Select(columns ...string) *Query {
+	q = q.derive()
 	q.method = selectMethod
 	if len(columns) == 0 {
 		var cls columnClause
@@ -159,21 +229,41 @@ func (q *Query) Select(columns ...string) *Query {
 
 // RawSelect add a raw expression to select clause
 func (q *Query) RawSelect(expression string) *Query {
+	return q.SelectRaw(expression)
+}
+
+// SelectRaw add a raw expression to select clause, "?" markers in it are bound to bindings
+func (q *Query) SelectRaw(expression string, bindings ...interface{}) *Query {
+	q = q.derive()
 	var cls rawColumnClause
 	cls.expression = expression
+	cls.bindings = bindings
 	cls.elementName = "RawColumn"
 	q.addElement(cls)
 	return q
 }
 
+// SelectSub add a scalar sub query to select clause, its arguments are bound to the statement
+func (q *Query) SelectSub(subQuery *Query, alias string) *Query {
+	q = q.derive()
+	var cls subColumnClause
+	cls.subQuery = subQuery
+	cls.alias = alias
+	cls.elementName = "column"
+	q.addElement(cls)
+	return q
+}
+
 // Distinct add DISTINCT clause to query
 func (q *Query) Distinct() *Query {
+	q = q.derive()
 	q.isDistinct = true
 	return q
 }
 
-// Where add WHERE constraint to query
+// Where add WHERE constraint to query, value can be a *Query which is compiled as a scalar sub query
 func (q *Query) Where(columnName string, sign string, value interface{}) *Query {
+	q = q.derive()
 	// cls := new(compareCondition)
 	var cls compareCondition
 	cls.columnName = columnName
@@ -191,6 +281,7 @@ func (q *Query) OrWhere(columnName string, sign string, value interface{}) *Quer
 }
 
 func (q *Query) WhereLike(columnName string, like string) *Query {
+	q = q.derive()
 	var cls likeCondition
 	cls.columnName = columnName
 	cls.like = like
@@ -214,6 +305,7 @@ func (q *Query) OrWhereNotLike(columnName string, like string) *Query {
 }
 
 func (q *Query) Between(columnName string, from interface{}, to interface{}) *Query {
+	q = q.derive()
 	// btw := new(betweenCondition)
 	var btw betweenCondition
 	btw.columnName = columnName
@@ -239,6 +331,7 @@ func (q *Query) OrNotBetween(columnName string, from interface{}, to interface{}
 }
 
 func (q *Query) WhereIn(columnName string, members ...interface{}) *Query {
+	q = q.derive()
 	// in := new(inCondition)
 	var in inCondition
 	in.columnName = columnName
@@ -263,6 +356,7 @@ func (q *Query) OrWhereNotIn(columnName string, members ...interface{}) *Query {
 }
 
 func (q *Query) WhereNull(columnName string) *Query {
+	q = q.derive()
 	// cls := new(NullCondition)
 	var cls nullCondition
 	cls.columnName = columnName
@@ -320,6 +414,7 @@ func (q *Query) OrWhereFalse(columnName string) *Query {
 
 // WhereInQuery add a sub query to query
 func (q *Query) WhereInQuery(columnName string, subQuery *Query) *Query {
+	q = q.derive()
 	var cls inQueryCondition
 	cls.columnName = columnName
 	cls.subQuery = subQuery
@@ -343,6 +438,7 @@ func (q *Query) OrWhereNotInQuery(columnName string, subQuery *Query) *Query {
 }
 
 func (q *Query) WhereExists(subQuery *Query) *Query {
+	q = q.derive()
 	var cls existsCondition
 	cls.subQuery = subQuery
 	cls.elementName = "where"
@@ -356,7 +452,50 @@ func (q *Query) WhereNotExists(subQuery *Query) *Query {
 	return q.Not().WhereExists(subQuery)
 }
 
+// WhereRaw add a raw expression to where clause, "?" markers in it are bound to bindings
+func (q *Query) WhereRaw(expression string, bindings ...interface{}) *Query {
+	q = q.derive()
+	var cls rawCodition
+	cls.expression = expression
+	cls.bindings = bindings
+	cls.elementName = "where"
+	cls.isNot = q.getNot()
+	cls.isOr = q.getOr()
+	q.addElement(cls)
+	return q
+}
+
+func (q *Query) OrWhereRaw(expression string, bindings ...interface{}) *Query {
+	return q.Or().WhereRaw(expression, bindings...)
+}
+
+// WhereCond add a user-defined condition or a condition of a typed Column to query
+func (q *Query) WhereCond(cond Condition) *Query {
+	q = q.derive()
+	if cc, ok := cond.(columnCondition); ok {
+		// conditions of typed columns are the elements of the string based methods
+		var flags conditionClause
+		flags.elementName = "where"
+		flags.isNot = q.getNot()
+		flags.isOr = q.getOr()
+		q.addElement(cc.make(flags))
+		return q
+	}
+	var cls customCondition
+	cls.cond = cond
+	cls.elementName = "where"
+	cls.isNot = q.getNot()
+	cls.isOr = q.getOr()
+	q.addElement(cls)
+	return q
+}
+
+func (q *Query) OrWhereCond(cond Condition) *Query {
+	return q.Or().WhereCond(cond)
+}
+
 func (q *Query) join(typ joinType, tableName, leftTable, sign, rightTable string) *Query {
+	q = q.derive()
 	var cls joinClause
 	cls.joinTyp = typ
 	cls.left = leftTable
@@ -385,6 +524,7 @@ func (q *Query) Join(tableName, leftColumn, sign, rightColumn string) *Query {
 
 // OrderBy add ORDER BY clause to query
 func (q *Query) OrderBy(columnName string) *Query {
+	q = q.derive()
 	// cls := new(orderByClause)
 	var cls orderByClause
 	cls.columnName = columnName
@@ -395,6 +535,7 @@ func (q *Query) OrderBy(columnName string) *Query {
 
 // OrderByDesc add ORDER BY DESC clause to query
 func (q *Query) OrderByDesc(columnName string) *Query {
+	q = q.derive()
 	// cls := new(orderByClause)
 	var cls orderByClause
 	cls.columnName = columnName
@@ -404,8 +545,74 @@ func (q *Query) OrderByDesc(columnName string) *Query {
 	return q
 }
 
+// OrderByNulls add ORDER BY clause with the position of NULL values, direction is "asc" or "desc" in any
+// case, another direction is a compile error. MySQL orders by ISNULL(column) first
+func (q *Query) OrderByNulls(columnName string, direction string, nullsFirst bool) *Query {
+	q = q.derive()
+	var cls orderByClause
+	cls.columnName = columnName
+	cls.direction = strings.TrimSpace(direction)
+	cls.desc = strings.EqualFold(cls.direction, kwDESC)
+	cls.nullsOrdered = true
+	cls.nullsFirst = nullsFirst
+	cls.elementName = "order"
+	q.addElement(cls)
+	return q
+}
+
+// InRandomOrder add ORDER BY RAND() or RANDOM() by the dialect
+func (q *Query) InRandomOrder() *Query {
+	q = q.derive()
+	var cls randomOrderClause
+	cls.elementName = "order"
+	q.addElement(cls)
+	return q
+}
+
+// OrderByField order rows by the position of column's value in values, other values come last on every
+// dialect. values must not be empty
+func (q *Query) OrderByField(columnName string, values ...interface{}) *Query {
+	q = q.derive()
+	var cls fieldOrderClause
+	cls.columnName = columnName
+	cls.values = values
+	cls.elementName = "order"
+	q.addElement(cls)
+	return q
+}
+
+// Reorder remove all ORDER BY clauses of query
+func (q *Query) Reorder() *Query {
+	q = q.derive()
+	return q.clearElements("order")
+}
+
+// OrderByRaw add a raw expression to ORDER BY clause, "?" markers in it are bound to bindings
+func (q *Query) OrderByRaw(expression string, bindings ...interface{}) *Query {
+	q = q.derive()
+	var cls rawExprClause
+	cls.expression = expression
+	cls.bindings = bindings
+	cls.elementName = "order"
+	q.addElement(cls)
+	return q
+}
+
+// GroupByRaw add a raw expression to GROUP BY clause after the columns of GroupBy, "?" markers in it are
+// bound to bindings
+func (q *Query) GroupByRaw(expression string, bindings ...interface{}) *Query {
+	q = q.derive()
+	var cls rawExprClause
+	cls.expression = expression
+	cls.bindings = bindings
+	cls.elementName = "groupRaw"
+	q.addElement(cls)
+	return q
+}
+
 // GroupBy add GROUP BY clause to query
 func (q *Query) GroupBy(columnNames ...string) *Query {
+	q = q.derive()
 	// cls := new(groupByClause)
 	var cls groupByClause
 	cls.columnNames = columnNames
@@ -414,8 +621,59 @@ func (q *Query) GroupBy(columnNames ...string) *Query {
 	return q
 }
 
+// GroupByRollup add GROUP BY ROLLUP clause to query, MySQL writes it as "GROUP BY ... WITH ROLLUP"
+func (q *Query) GroupByRollup(columnNames ...string) *Query {
+	q = q.derive()
+	var cls groupByClause
+	cls.columnNames = columnNames
+	cls.kind = rollupGrouping
+	cls.elementName = "group"
+	q.replaceOrAdd(cls)
+	return q
+}
+
+// GroupByCube add GROUP BY CUBE clause to query
+func (q *Query) GroupByCube(columnNames ...string) *Query {
+	q = q.derive()
+	var cls groupByClause
+	cls.columnNames = columnNames
+	cls.kind = cubeGrouping
+	cls.elementName = "group"
+	q.replaceOrAdd(cls)
+	return q
+}
+
+// GroupByGroupingSets add GROUP BY GROUPING SETS clause to query, an empty set is the grand total
+func (q *Query) GroupByGroupingSets(sets [][]string) *Query {
+	q = q.derive()
+	var cls groupByClause
+	cls.sets = sets
+	cls.kind = setsGrouping
+	cls.elementName = "group"
+	q.replaceOrAdd(cls)
+	return q
+}
+
+// Grouping add GROUPING(columns) to select clause, it tells the subtotal rows of ROLLUP, CUBE and GROUPING
+// SETS apart
+func (q *Query) Grouping(columnNames ...string) *Query {
+	return q.GroupingAs("", columnNames...)
+}
+
+// GroupingAs add GROUPING(columns) AS alias to select clause
+func (q *Query) GroupingAs(alias string, columnNames ...string) *Query {
+	q = q.derive()
+	var cls groupingColumnClause
+	cls.columnNames = columnNames
+	cls.alias = alias
+	cls.elementName = "column"
+	q.addElement(cls)
+	return q
+}
+
 // Having add Having clause to query
 func (q *Query) Having(columnName string, sign string, value interface{}) *Query {
+	q = q.derive()
 	var cls compareCondition
 	cls.columnName = columnName
 	cls.sign = sign
@@ -431,9 +689,12 @@ func (q *Query) OrHaving(columnName string, sign string, value interface{}) *Que
 	return q.Or().Having(columnName, sign, value)
 }
 
-func (q *Query) HavingRaw(expression string) *Query {
+// HavingRaw add a raw expression to having clause, "?" markers in it are bound to bindings
+func (q *Query) HavingRaw(expression string, bindings ...interface{}) *Query {
+	q = q.derive()
 	var cls rawCodition
 	cls.expression = expression
+	cls.bindings = bindings
 	cls.elementName = "having"
 	cls.isNot = q.getNot()
 	cls.isOr = q.getOr()
@@ -441,12 +702,13 @@ func (q *Query) HavingRaw(expression string) *Query {
 	return q
 }
 
-func (q *Query) OrHavingRaw(expression string) *Query {
-	return q.Or().HavingRaw(expression)
+func (q *Query) OrHavingRaw(expression string, bindings ...interface{}) *Query {
+	return q.Or().HavingRaw(expression, bindings...)
 }
 
 // Limit add LIMIT clause to query
 func (q *Query) Limit(rowCount int) *Query {
+	q = q.derive()
 	// cls := new(limitClause)
 	var cls limitClause
 	if rowCount < 0 {
@@ -461,6 +723,7 @@ func (q *Query) Limit(rowCount int) *Query {
 
 // Offset add OFFSET clause to query
 func (q *Query) Offset(rows int) *Query {
+	q = q.derive()
 	// cls := new(offsetClause)
 	var cls offsetClause
 	if rows < 0 {
@@ -473,8 +736,27 @@ func (q *Query) Offset(rows int) *Query {
 	return q
 }
 
+// ForUpdate lock the selected rows until the end of the transaction with FOR UPDATE. A cluster builder
+// runs locking reads on the primary
+func (q *Query) ForUpdate() *Query {
+	q = q.derive()
+	var cls lockClause
+	cls.elementName = "lock"
+	q.replaceOrAdd(cls)
+	return q
+}
+
+// OnPrimary run the query on the primary database of a cluster builder, e.g. to read a row just written by
+// another process. Builders without replicas always use their database
+func (q *Query) OnPrimary() *Query {
+	q = q.derive()
+	q.onPrimary = true
+	return q
+}
+
 // Insert build a insert statement 
 func (q *Query) Insert(columns []string, values []interface{}) *Query {
+	q = q.derive()
 	q.method = insertMethod
 	q.clearElements("insert")
 	var cls insertClause
@@ -485,25 +767,44 @@ func (q *Query) Insert(columns []string, values []interface{}) *Query {
 	return q
 }
 
+// InsertFromQuery build a "INSERT INTO ... SELECT" statement, arguments of subq are bound to the statement
 func (q *Query) InsertFromQuery(subq *Query) *Query {
+	return q.InsertUsing(nil, subq)
+}
+
+// InsertUsing build a "INSERT INTO table (columns) SELECT" statement
+func (q *Query) InsertUsing(columns []string, subq *Query) *Query {
+	q = q.derive()
 	q.method = insertMethod
 	q.clearElements("insert")
 	var cls insertClause
 	cls.elementName = "insert"
+	cls.columns = columns
 	cls.subQuery = subq
 	q.addElement(cls)
 	return q
 }
 
+// InsertFromMap build a insert statement from a map, columns are sorted by name so the statement is stable
 func (q *Query) InsertFromMap(item map[string]interface{}) *Query {
+	q = q.derive()
 	q.method = insertMethod
 	q.clearElements("insert")
 	var cls insertClause
 	cls.elementName = "insert"
-	for k, v := range item {
-		cls.columns = append(cls.columns, k)
-		cls.values = append(cls.values, v)
-	}
+	cls.columns, cls.values = sortedPairs(item)
+	q.addElement(cls)
+	return q
+}
+
+// InsertPairs build a insert statement, columns keep the order they are given
+func (q *Query) InsertPairs(pairs ...Pair) *Query {
+	q = q.derive()
+	q.method = insertMethod
+	q.clearElements("insert")
+	var cls insertClause
+	cls.elementName = "insert"
+	cls.columns, cls.values = splitPairs(pairs)
 	q.addElement(cls)
 	return q
 }
@@ -512,12 +813,25 @@ func (q *Query) InsertFromMap(item map[string]interface{}) *Query {
 // func (q *Query) InsertObject(objs struct{}) *Query {
 // }
 
-// Update build a update statement
+// Update build a update statement, columns are sorted by name so the statement is stable
 func (q *Query) Update(item map[string]interface{}) *Query {
+	q = q.derive()
+	q.clearElements("update")
+	q.method = updateMethod
+	var cls updateClause
+	cls.columns, cls.values = sortedPairs(item)
+	cls.elementName = "update"
+	q.addElement(cls)
+	return q
+}
+
+// UpdatePairs build a update statement, columns keep the order they are given
+func (q *Query) UpdatePairs(pairs ...Pair) *Query {
+	q = q.derive()
 	q.clearElements("update")
 	q.method = updateMethod
 	var cls updateClause
-	cls.item = item
+	cls.columns, cls.values = splitPairs(pairs)
 	cls.elementName = "update"
 	q.addElement(cls)
 	return q
@@ -527,17 +841,45 @@ func (q *Query) Update(item map[string]interface{}) *Query {
 // func (q *Query) updateObject(obj struct{}) *Query {
 // }
 
+// PrimaryKey set the key column used when a dialect has to rewrite a multi-table update or delete into
+// "WHERE key IN (sub query)", default is "id"
+func (q *Query) PrimaryKey(columnName string) *Query {
+	q = q.derive()
+	q.keyColumn = columnName
+	return q
+}
+
 // Delete build a delete statement
 func (q *Query) Delete() *Query {
+	q = q.derive()
 	q.method = deleteMethod
 	return q
 }
 
+// Truncate build a statement that removes every row of the table
+func (q *Query) Truncate() *Query {
+	q = q.derive()
+	q.method = truncateMethod
+	return q
+}
+
+// AllowFullTable let an update or delete without WHERE clause pass the builder's safe mode
+func (q *Query) AllowFullTable() *Query {
+	q = q.derive()
+	q.allowFullTable = true
+	return q
+}
+
+// Compile compile the query to a SQLResult
+func (q *Query) Compile() (*SQLResult, error) {
+	cmpl := q.builder.cmpl.clone()
+	return cmpl.compile(q)
+}
+
 // ToString replace all placeholders to value in sql statement, and a error will be return when sql variable can't be
 // convert to string
 func (q *Query) ToString() (string, error) {
-	cmpl := q.builder.cmpl.clone()
-	rst, err := cmpl.compile(q)
+	rst, err := q.Compile()
 	if err != nil {
 		return "", err
 	}
@@ -546,9 +888,7 @@ func (q *Query) ToString() (string, error) {
 
 // ToPrepared return a string with placeholders, and a variables list
 func (q *Query) ToPrepared() (string, []interface{}, error) {
-//	var rst *SQLResult
-	cmpl := q.builder.cmpl.clone()
-	rst, err := cmpl.compile(q)
+	rst, err := q.Compile()
 	if err != nil {
 		return "", nil, err
 	}
@@ -556,20 +896,89 @@ func (q *Query) ToPrepared() (string, []interface{}, error) {
 	return sql, args, nil
 }
 
-// Do execute the query with DB.QueryRow() 
-func (q *Query) Do() (*sql.Row, error) {
-	sql, values, err := q.ToPrepared()
+// Param return a placeholder of a named parameter, its value is given by Params when the query is executed.
+// With the Naming pattern other values get generated names like "param0", a parameter named like one which
+// is already generated fails the compilation
+func (q *Query) Param(name string) NamedParam {
+	return NamedParam{name}
+}
+
+// prepare compile the query and fill the values of named parameters
+func (q *Query) prepare(params []Params) (string, []interface{}, error) {
+	rst, err := q.Compile()
+	if err != nil {
+		return "", nil, err
+	}
+	values, err := rst.Args(params...)
+	if err != nil {
+		return "", nil, err
+	}
+	return rst.rawSQL, values, nil
+}
+
+// info return the method and table of the query for hooks
+func (q *Query) info() stmtInfo {
+	info := stmtInfo{method: q.method.String(), primary: q.onPrimary}
+	if elm, ok := q.getElement("from"); ok {
+		info.table = elm.(fromClause).tableName
+	}
+	if _, ok := q.getElement("lock"); ok {
+		info.primary = true
+	}
+	return info
+}
+
+// Do execute the query with DB.QueryRowContext(), params give the values of named parameters
+func (q *Query) Do(ctx context.Context, params ...Params) (*sql.Row, error) {
+	sql, values, err := q.prepare(params)
+	if err != nil {
+		return nil, err
+	}
+	return q.builder.queryRow(ctx, q.info(), sql, values)
+}
+
+// Get execute the query with DB.QueryContext(), params give the values of named parameters
+func (q *Query) Get(ctx context.Context, params ...Params) (*sql.Rows, error) {
+	sql, values, err := q.prepare(params)
 	if err != nil {
 		return nil, err
 	}
-	return q.builder.pool.QueryRow(sql, values...), nil
+	return q.builder.query(ctx, q.info(), sql, values)
 }
 
-// Get execute the query with DB.Query()
-func (q *Query) Get() (*sql.Rows, error) {
-	sql, values, err := q.ToPrepared()
+// Exec execute the query with DB.ExecContext(), params give the values of named parameters
+func (q *Query) Exec(ctx context.Context, params ...Params) (sql.Result, error) {
+	sql, values, err := q.prepare(params)
 	if err != nil {
 		return nil, err
 	}
-	return q.builder.pool.Query(sql, values...)
+	return q.builder.exec(ctx, q.info(), sql, values)
+}
+
+// DeleteInBatches delete the matched rows batchSize rows at a time until no row is affected, so every
+// statement only holds its locks shortly. It returns the number of deleted rows
+func (q *Query) DeleteInBatches(ctx context.Context, batchSize int) (int64, error) {
+	if batchSize <= 0 {
+		return 0, errors.New("batch size must great than 0")
+	}
+	bq := q.Clone().Delete().Limit(batchSize)
+
+	var total int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+		res, err := bq.Exec(ctx)
+		if err != nil {
+			return total, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return total, err
+		}
+		if n == 0 {
+			return total, nil
+		}
+		total += n
+	}
 }
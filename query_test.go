@@ -4,10 +4,14 @@ SQL builder implement by Golang
 package gqbuilder
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
+
+	"github.com/paulnjiang/gqbuilder/internal/fakedb"
 )
 
 const dbtype = MySQL
@@ -303,4 +307,404 @@ func TestDelete(t *testing.T) {
 		return
 	}
 	fmt.Printf("test insert: %s \n", ssql)
-}
\ No newline at end of file
+}
+
+func TestStableWrites(t *testing.T) {
+	var con *sql.DB
+	bdr := NewBuilder(dbtype, con)
+	item := map[string]interface{}{"name": "bob", "age": 19, "email": "bob@example.com"}
+	for i := 0; i < 10; i++ {
+		raw, _, e := bdr.Query("user").Update(item).Where("id", "=", 119).ToPrepared()
+		if e != nil {
+			t.Errorf("test stable update error: %s\n", e)
+			return
+		}
+		if raw != "UPDATE `user` SET `age`=?, `email`=?, `name`=? WHERE `id` = ?" {
+			t.Errorf("test stable update: unexpected sql %s\n", raw)
+			return
+		}
+		raw, _, e = bdr.Query("user").InsertFromMap(item).ToPrepared()
+		if e != nil {
+			t.Errorf("test stable insert error: %s\n", e)
+			return
+		}
+		if raw != "INSERT INTO `user` (`age`, `email`, `name`) VALUES (?, ?, ?)" {
+			t.Errorf("test stable insert: unexpected sql %s\n", raw)
+			return
+		}
+	}
+}
+
+func TestPairs(t *testing.T) {
+	var con *sql.DB
+	bdr := NewBuilder(dbtype, con)
+	raw, args, e := bdr.Query("user").UpdatePairs(Pair{"name", "bob"}, Pair{"age", 19}).Where("id", "=", 119).ToPrepared()
+	if e != nil {
+		t.Errorf("test update pairs error: %s\n", e)
+		return
+	}
+	if raw != "UPDATE `user` SET `name`=?, `age`=? WHERE `id` = ?" || len(args) != 3 || args[0] != "bob" {
+		t.Errorf("test update pairs: unexpected sql %s %v\n", raw, args)
+	}
+	raw, args, e = bdr.Query("user").InsertPairs(Pair{"name", "bob"}, Pair{"age", 19}).ToPrepared()
+	if e != nil {
+		t.Errorf("test insert pairs error: %s\n", e)
+		return
+	}
+	if raw != "INSERT INTO `user` (`name`, `age`) VALUES (?, ?)" || len(args) != 2 || args[1] != 19 {
+		t.Errorf("test insert pairs: unexpected sql %s %v\n", raw, args)
+	}
+}
+
+func TestJoinedWrites(t *testing.T) {
+	var con *sql.DB
+	expects := map[databaseType][]string{
+		MySQL: {
+			"UPDATE `user` INNER JOIN `address` ON `user`.`id` = `address`.`uid` SET `user`.`city`=? WHERE `address`.`zip` = ?",
+			"DELETE `user` FROM `user` INNER JOIN `address` ON `user`.`id` = `address`.`uid` WHERE `address`.`zip` = ?",
+		},
+		PostgreSQL: {
+			`UPDATE "user" SET "city"=$1 FROM "address" WHERE "user"."id" = "address"."uid" AND ("address"."zip" = $2)`,
+			`DELETE FROM "user" USING "address" WHERE "user"."id" = "address"."uid" AND ("address"."zip" = $1)`,
+		},
+		SQLite: {
+			`UPDATE "user" SET "city"=? WHERE "id" IN (SELECT "user"."id" FROM "user" INNER JOIN "address" ON "user"."id" = "address"."uid" WHERE "address"."zip" = ?)`,
+			`DELETE FROM "user" WHERE "id" IN (SELECT "user"."id" FROM "user" INNER JOIN "address" ON "user"."id" = "address"."uid" WHERE "address"."zip" = ?)`,
+		},
+	}
+	for driver, expect := range expects {
+		bdr := NewBuilder(driver, con)
+		q := bdr.Query("user").Join("address", "user.id", "=", "address.uid").Where("address.zip", "=", "10001")
+		raw, _, e := q.Update(map[string]interface{}{"user.city": "NYC"}).ToPrepared()
+		if e != nil {
+			t.Errorf("test joined update error: %s\n", e)
+			return
+		}
+		if raw != expect[0] {
+			t.Errorf("test joined update: unexpected sql %s\n", raw)
+		}
+		q = bdr.Query("user").Join("address", "user.id", "=", "address.uid").Where("address.zip", "=", "10001")
+		raw, _, e = q.Delete().ToPrepared()
+		if e != nil {
+			t.Errorf("test joined delete error: %s\n", e)
+			return
+		}
+		if raw != expect[1] {
+			t.Errorf("test joined delete: unexpected sql %s\n", raw)
+		}
+	}
+}
+
+func TestOrderedWrites(t *testing.T) {
+	var con *sql.DB
+	expects := map[databaseType]string{
+		MySQL:      "DELETE FROM `log` WHERE `level` = ? ORDER BY `created` ASC LIMIT 100",
+		PostgreSQL: `DELETE FROM "log" WHERE "id" IN (SELECT "log"."id" FROM "log" WHERE "level" = $1 ORDER BY "created" ASC LIMIT 100)`,
+		SQLite:     `DELETE FROM "log" WHERE "id" IN (SELECT "log"."id" FROM "log" WHERE "level" = ? ORDER BY "created" ASC LIMIT 100)`,
+	}
+	for driver, expect := range expects {
+		bdr := NewBuilder(driver, con)
+		q := bdr.Query("log").Delete().Where("level", "=", "debug").OrderBy("created").Limit(100)
+		raw, _, e := q.ToPrepared()
+		if e != nil {
+			t.Errorf("test ordered delete error: %s\n", e)
+			return
+		}
+		if raw != expect {
+			t.Errorf("test ordered delete: unexpected sql %s\n", raw)
+		}
+	}
+
+	bdr := NewBuilder(MySQL, con)
+	q := bdr.Query("user").Join("address", "user.id", "=", "address.uid").Update(map[string]interface{}{"active": false}).Limit(10).AllowFullTable()
+	raw, _, e := q.ToPrepared()
+	if e != nil {
+		t.Errorf("test ordered update error: %s\n", e)
+		return
+	}
+	if raw != "UPDATE `user` SET `active`=? WHERE `id` IN (SELECT `id` FROM (SELECT `user`.`id` FROM `user` INNER JOIN `address` ON `user`.`id` = `address`.`uid` LIMIT 10) AS `gqb_keys`)" {
+		t.Errorf("test ordered update: unexpected sql %s\n", raw)
+	}
+}
+
+func TestDeleteInBatches(t *testing.T) {
+	fake, db := fakedb.New("batches")
+	left := int64(250)
+	fake.Affected = func(query string) int64 {
+		n := left
+		if n > 100 {
+			n = 100
+		}
+		left -= n
+		return n
+	}
+	bdr := NewBuilder(MySQL, db)
+	n, e := bdr.Query("log").Where("level", "=", "debug").DeleteInBatches(context.Background(), 100)
+	if e != nil {
+		t.Errorf("test delete in batches error: %s\n", e)
+		return
+	}
+	if n != 250 || len(fake.Execs) != 4 {
+		t.Errorf("test delete in batches: expect 250 rows in 4 statements, got %d in %d\n", n, len(fake.Execs))
+		return
+	}
+	if fake.Execs[0] != "DELETE FROM `log` WHERE `level` = ? LIMIT 100" {
+		t.Errorf("test delete in batches: unexpected sql %s\n", fake.Execs[0])
+	}
+}
+
+func TestSafeMode(t *testing.T) {
+	var con *sql.DB
+	bdr := NewBuilder(dbtype, con)
+	_, e := bdr.Query("user").Delete().ToString()
+	if !errors.Is(e, ErrUnsafeWrite) {
+		t.Errorf("test safe mode: expect ErrUnsafeWrite, got %v\n", e)
+	}
+	var uw *UnsafeWriteError
+	_, e = bdr.Query("user").Update(map[string]interface{}{"age": 1}).ToString()
+	if !errors.As(e, &uw) || uw.Table != "user" {
+		t.Errorf("test safe mode: expect UnsafeWriteError, got %v\n", e)
+	}
+	if _, e = bdr.Query("user").Delete().AllowFullTable().ToString(); e != nil {
+		t.Errorf("test safe mode: allow full table error: %s\n", e)
+	}
+	ssql, e := bdr.Query("user").Truncate().ToString()
+	if e != nil || ssql != "TRUNCATE TABLE `user`" {
+		t.Errorf("test truncate: sql: %s error: %v\n", ssql, e)
+	}
+	bdr.SetSafeMode(false)
+	if _, e = bdr.Query("user").Delete().ToString(); e != nil {
+		t.Errorf("test safe mode off error: %s\n", e)
+	}
+}
+
+func TestClone(t *testing.T) {
+	var con *sql.DB
+	bdr := NewBuilder(dbtype, con)
+	sub := bdr.Query("address").Select("uid").WhereIn("city", "NYC", "LA")
+	base := bdr.Query("user").Select("id").Where("age", ">", 20).WhereInQuery("id", sub)
+	want, _ := base.ToString()
+
+	cp := base.Clone()
+	q := base.Clone().Where("name", "=", "bob")
+	q.Or()
+	qq := q.Clone().Where("id", "=", 1)
+	sub.WhereIn("zip", "10001")
+	if ssql, _ := cp.ToString(); ssql != want {
+		t.Errorf("test clone: sub query should not be shared with the original: %s\n", ssql)
+	}
+	if ssql, _ := qq.ToString(); ssql != want+" AND `name` = 'bob' AND `id` = 1" {
+		t.Errorf("test clone: pending Or() should not be copied: %s\n", ssql)
+	}
+
+	// copy-on-write
+	imm := bdr.Query("user").Select("id").Immutable().Where("age", ">", 20)
+	want, _ = imm.ToString()
+	a := imm.OrWhere("id", "=", 1)
+	b := imm.Limit(10)
+	if ssql, _ := imm.ToString(); ssql != want {
+		t.Errorf("test immutable: base query changed: %s\n", ssql)
+	}
+	if ssql, _ := a.ToString(); ssql != want+" OR `id` = 1" {
+		t.Errorf("test immutable: %s\n", ssql)
+	}
+	if ssql, _ := b.ToString(); ssql != want+" LIMIT 10" {
+		t.Errorf("test immutable: %s\n", ssql)
+	}
+}
+
+func TestInsertUsing(t *testing.T) {
+	var con *sql.DB
+	bdr := NewBuilder(PostgreSQL, con)
+	other := NewBuilder(MySQL, con)
+	qq := other.Query("archive").Select("name", "avatar").Where("avatar", "=", []byte{0x1}).Where("age", ">", 20)
+	raw, args, e := bdr.Query("user").InsertUsing([]string{"name", "avatar"}, qq).ToPrepared()
+	if e != nil {
+		t.Errorf("test insert using error: %s\n", e)
+		return
+	}
+	if raw != `INSERT INTO "user" ("name", "avatar") SELECT "name", "avatar" FROM "archive" WHERE "avatar" = $1 AND "age" > $2` || len(args) != 2 {
+		t.Errorf("test insert using: unexpected sql %s %v\n", raw, args)
+	}
+	raw, _, e = bdr.Query("user").InsertFromQuery(bdr.Query("archive")).ToPrepared()
+	if e != nil || raw != `INSERT INTO "user" SELECT * FROM "archive"` {
+		t.Errorf("test insert from query: sql: %s error: %v\n", raw, e)
+	}
+}
+
+func TestScalarSubquery(t *testing.T) {
+	var con *sql.DB
+	bdr := NewBuilder(PostgreSQL, con)
+	orders := bdr.Query("orders").RawSelect("count(*)").Where("status", "=", "paid")
+	avg := bdr.Query("user").RawSelect("avg(age)").Where("active", "=", true)
+	q := bdr.Query("user").Select("id").SelectSub(orders, "paid").Where("age", ">", avg).Where("id", "<", 100)
+	raw, args, e := q.ToPrepared()
+	if e != nil {
+		t.Errorf("test scalar sub query error: %s\n", e)
+		return
+	}
+	expect := `SELECT "id", (SELECT count(*) FROM "orders" WHERE "status" = $1) AS "paid" FROM "user" ` +
+		`WHERE "age" > (SELECT avg(age) FROM "user" WHERE "active" = $2) AND "id" < $3`
+	if raw != expect || len(args) != 3 || args[0] != "paid" || args[2] != 100 {
+		t.Errorf("test scalar sub query: unexpected sql %s %v\n", raw, args)
+	}
+
+	loop := bdr.Query("user")
+	loop.WhereInQuery("id", loop)
+	if _, e = loop.ToString(); e == nil {
+		t.Errorf("test scalar sub query: expect an error for a query containing itself\n")
+	}
+}
+
+func TestRawBindings(t *testing.T) {
+	var con *sql.DB
+	bdr := NewBuilder(PostgreSQL, con)
+	q := bdr.Query("orders").Select("uid").
+		SelectRaw("sum(amount) * ? AS total", 1.1).
+		Where("status", "=", "paid").
+		WhereRaw("created_at > now() - ?::interval", "7 days").
+		OrWhereRaw("note = '?' AND flag = ?", true).
+		GroupBy("uid").GroupByRaw("date_trunc(?, created_at)", "day").
+		HavingRaw("sum(amount) > ?", 100).
+		OrderByRaw("abs(uid - ?)", 5)
+	raw, args, e := q.ToPrepared()
+	if e != nil {
+		t.Errorf("test raw bindings error: %s\n", e)
+		return
+	}
+	expect := `SELECT "uid", sum(amount) * $1 AS total FROM "orders" WHERE "status" = $2 AND created_at > now() - $3::interval ` +
+		`OR note = '?' AND flag = $4 GROUP BY "uid", date_trunc($5, created_at) HAVING sum(amount) > $6 ORDER BY abs(uid - $7)`
+	if raw != expect || len(args) != 7 {
+		t.Errorf("test raw bindings: unexpected sql %s %v\n", raw, args)
+	}
+	if _, e = bdr.Query("orders").WhereRaw("a = ? AND b = ?", 1).ToString(); e == nil {
+		t.Errorf("test raw bindings: expect an error for missing bindings\n")
+	}
+	_, e = bdr.Query("orders").SelectRaw("?, ?", 1).ToString()
+	if e == nil || e.Error() != `CompileSelect: compileColumns: expression "?, ?" has more markers than 1 bindings` {
+		t.Errorf("test raw bindings: unexpected error for missing bindings %v\n", e)
+	}
+
+	raw, args, e = bdr.Query("orders").RawSelect("data ? 'k' AS has").WhereRaw("tags ?| array['a', 'b']").
+		HavingRaw("count(*) > 1").OrderByRaw("data ?& array['c']").Where("id", "=", 1).ToPrepared()
+	if e != nil {
+		t.Errorf("test raw bindings error: %s\n", e)
+		return
+	}
+	expect = `SELECT data ? 'k' AS has FROM "orders" WHERE tags ?| array['a', 'b'] AND "id" = $1 ` +
+		`HAVING count(*) > 1 ORDER BY data ?& array['c']`
+	if raw != expect || len(args) != 1 {
+		t.Errorf("test raw bindings: unexpected sql without bindings %s %v\n", raw, args)
+	}
+}
+
+func TestOrderEnhancements(t *testing.T) {
+	var con *sql.DB
+	expects := map[databaseType]string{
+		MySQL: "SELECT `id` FROM `user` ORDER BY ISNULL(`login`) ASC, `login` DESC, " +
+			"CASE `status` WHEN ? THEN 0 WHEN ? THEN 1 ELSE 2 END, RAND()",
+		PostgreSQL: `SELECT "id" FROM "user" ORDER BY "login" DESC NULLS LAST, ` +
+			`CASE "status" WHEN $1 THEN 0 WHEN $2 THEN 1 ELSE 2 END, RANDOM()`,
+		SQLite: `SELECT "id" FROM "user" ORDER BY "login" DESC NULLS LAST, ` +
+			`CASE "status" WHEN ? THEN 0 WHEN ? THEN 1 ELSE 2 END, RANDOM()`,
+	}
+	for driver, expect := range expects {
+		bdr := NewBuilder(driver, con)
+		q := bdr.Query("user").Select("id").OrderBy("name").Reorder().
+			OrderByNulls("login", "desc", false).OrderByField("status", "active", "pending").InRandomOrder()
+		raw, args, e := q.ToPrepared()
+		if e != nil {
+			t.Errorf("test order enhancements error: %s\n", e)
+			return
+		}
+		if raw != expect || len(args) != 2 {
+			t.Errorf("test order enhancements: unexpected sql %s %v\n", raw, args)
+		}
+		if _, _, e = bdr.Query("user").OrderByField("status").ToPrepared(); e == nil {
+			t.Errorf("test order enhancements: expect an error for field order without values on %s\n", driver)
+		}
+		if _, _, e = bdr.Query("user").OrderByNulls("login", " ASC ", true).ToPrepared(); e != nil {
+			t.Errorf("test order enhancements error: %s\n", e)
+			return
+		}
+		if _, _, e = bdr.Query("user").OrderByNulls("login", "dsc", false).ToPrepared(); e == nil {
+			t.Errorf("test order enhancements: expect an error for an unknown direction on %s\n", driver)
+		}
+	}
+}
+
+func TestGroupingSets(t *testing.T) {
+	var con *sql.DB
+	expects := map[databaseType]string{
+		MySQL: "SELECT `region`, `product`, GROUPING(`region`) AS `g` FROM `sales` GROUP BY `region`, `product` WITH ROLLUP",
+		PostgreSQL: `SELECT "region", "product", GROUPING("region") AS "g" FROM "sales" GROUP BY ROLLUP ("region", "product")`,
+	}
+	for driver, expect := range expects {
+		bdr := NewBuilder(driver, con)
+		raw, e := bdr.Query("sales").Select("region", "product").GroupingAs("g", "region").
+			GroupByRollup("region", "product").ToString()
+		if e != nil {
+			t.Errorf("test grouping sets error: %s\n", e)
+			return
+		}
+		if raw != expect {
+			t.Errorf("test grouping sets: unexpected sql %s\n", raw)
+		}
+	}
+
+	bdr := NewBuilder(PostgreSQL, con)
+	raw, e := bdr.Query("sales").Select("region").GroupByCube("region", "product").ToString()
+	if e != nil || raw != `SELECT "region" FROM "sales" GROUP BY CUBE ("region", "product")` {
+		t.Errorf("test grouping sets: unexpected cube %s %v\n", raw, e)
+	}
+	raw, e = bdr.Query("sales").Select("region").
+		GroupByGroupingSets([][]string{{"region", "product"}, {"region"}, {}}).ToString()
+	if e != nil || raw != `SELECT "region" FROM "sales" GROUP BY GROUPING SETS (("region", "product"), ("region"), ())` {
+		t.Errorf("test grouping sets: unexpected sets %s %v\n", raw, e)
+	}
+
+	_, e = NewBuilder(MySQL, con).Query("sales").GroupByCube("region").ToString()
+	if !errors.Is(e, ErrUnsupported) {
+		t.Errorf("test grouping sets: expect ErrUnsupported for CUBE on MySQL, got %v\n", e)
+	}
+	_, e = NewBuilder(SQLite, con).Query("sales").GroupByRollup("region").ToString()
+	if !errors.Is(e, ErrUnsupported) {
+		t.Errorf("test grouping sets: expect ErrUnsupported for ROLLUP on SQLite, got %v\n", e)
+	}
+	_, e = NewBuilder(SQLite, con).Query("sales").Grouping("region").ToString()
+	var ce *CompileError
+	if !errors.Is(e, ErrUnsupported) || !errors.As(e, &ce) {
+		t.Errorf("test grouping sets: expect a CompileError for GROUPING() on SQLite, got %v\n", e)
+	}
+
+	raw, e = bdr.Query("sales").Select("region").Grouping("region").GroupByRollup("region").ToString()
+	if e != nil || raw != `SELECT "region", GROUPING("region") FROM "sales" GROUP BY ROLLUP ("region")` {
+		t.Errorf("test grouping sets: unexpected grouping %s %v\n", raw, e)
+	}
+	if _, e = bdr.Query("sales").Grouping().GroupByRollup("region").ToString(); e == nil {
+		t.Errorf("test grouping sets: expect an error for GROUPING() without columns\n")
+	}
+}
+
+func TestTypedColumns(t *testing.T) {
+	var con *sql.DB
+	bdr := NewBuilder(PostgreSQL, con)
+	users := Table("users").As("u")
+	typed, e := bdr.Query(users.String()).Select(users.Col("id").String(), users.Col("name").As("n")).
+		WhereCond(users.Col("age").Gt(20)).
+		Not().WhereCond(users.Col("status").In("banned", "closed")).
+		Or().WhereCond(users.Col("email").IsNotNull()).
+		ToString()
+	if e != nil {
+		t.Errorf("test typed columns error: %s\n", e)
+		return
+	}
+	plain, e := bdr.Query("users as u").Select("u.id", "u.name as n").
+		Where("u.age", ">", 20).
+		WhereNotIn("u.status", "banned", "closed").
+		OrWhereNotNull("u.email").
+		ToString()
+	if e != nil || typed != plain {
+		t.Errorf("test typed columns: expect %s, got %s\n", plain, typed)
+	}
+}
@@ -0,0 +1,256 @@
+package gqbuilder
+
+/*
+	convert bind variables between placeholder styles
+*/
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// bindToken is a bind variable found in a sql text
+type bindToken struct {
+	start   int
+	end     int
+	pattern bindPattern
+	name    string // name of Naming variables
+	index   int    // 1-based position of Ordinal variables
+}
+
+// scanBindTokens find the bind variables of sqlText, string literals, quoted identifiers, comments and
+// PostgreSQL "::" casts are skipped
+func scanBindTokens(sqlText string) []bindToken {
+	var tokens []bindToken
+	n := len(sqlText)
+	for i := 0; i < n; i++ {
+		ch := sqlText[i]
+		switch {
+		case ch == '\'' || ch == '"' || ch == '`':
+			// literal or quoted identifier, a doubled quote is an escaped one
+			for i++; i < n; i++ {
+				if sqlText[i] == ch {
+					if i+1 < n && sqlText[i+1] == ch {
+						i++
+						continue
+					}
+					break
+				}
+			}
+		case ch == '-' && i+1 < n && sqlText[i+1] == '-':
+			for i += 2; i < n && sqlText[i] != '\n'; i++ {
+			}
+		case ch == '/' && i+1 < n && sqlText[i+1] == '*':
+			end := strings.Index(sqlText[i+2:], "*/")
+			if end < 0 {
+				return tokens
+			}
+			i += end + 3
+		case ch == ':' && i+1 < n && sqlText[i+1] == ':':
+			i++
+		case ch == '@' && i+1 < n && sqlText[i+1] == '@':
+			// MySQL system variable
+			for i += 2; i < n && isNameByte(sqlText[i]); i++ {
+			}
+			i--
+		case ch == '?':
+			tokens = append(tokens, bindToken{start: i, end: i + 1, pattern: PlaceHolder})
+		case ch == '$':
+			j := i + 1
+			for j < n && sqlText[j] >= '0' && sqlText[j] <= '9' {
+				j++
+			}
+			if j > i+1 {
+				idx, _ := strconv.Atoi(sqlText[i+1 : j])
+				tokens = append(tokens, bindToken{start: i, end: j, pattern: Ordinal, index: idx})
+				i = j - 1
+			}
+		case ch == ':' || ch == '@':
+			j := i + 1
+			if j < n && (sqlText[j] == '_' || isLetter(sqlText[j])) {
+				for j < n && isNameByte(sqlText[j]) {
+					j++
+				}
+				tokens = append(tokens, bindToken{start: i, end: j, pattern: Naming, name: sqlText[i+1 : j]})
+				i = j - 1
+			}
+		case isNameByte(ch):
+			// a ':' or '$' inside a word isn't a bind variable
+			for i+1 < n && (isNameByte(sqlText[i+1]) || sqlText[i+1] == '$') {
+				i++
+			}
+		}
+	}
+	return tokens
+}
+
+func isLetter(ch byte) bool {
+	return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
+
+func isNameByte(ch byte) bool {
+	return ch == '_' || isLetter(ch) || (ch >= '0' && ch <= '9')
+}
+
+// defaultSymbol return the symbol prefix usually written with pattern
+func defaultSymbol(bp bindPattern) string {
+	switch bp {
+	case Ordinal:
+		return "$"
+	case Naming:
+		return ":"
+	default:
+		return "?"
+	}
+}
+
+// Rebind convert the bind variables of sqlText written in from pattern to pattern to. Both ":name" and "@name"
+// are read as Naming variables, which are written as ":name", positional variables get names "param0",
+// "param1"... Use RebindSymbol to write another prefix
+func Rebind(sqlText string, from, to bindPattern) string {
+	return RebindSymbol(sqlText, from, to, defaultSymbol(to))
+}
+
+// RebindSymbol is Rebind writing the variables with symbol prefix, e.g. "@" for "@name"
+func RebindSymbol(sqlText string, from, to bindPattern, symbol string) string {
+	var buf strings.Builder
+	last := 0
+	seq := 0
+	names := make(map[string]int)
+	for _, tok := range scanBindTokens(sqlText) {
+		if tok.pattern != from {
+			continue
+		}
+		buf.WriteString(sqlText[last:tok.start])
+		last = tok.end
+		switch to {
+		case PlaceHolder:
+			buf.WriteString(symbol)
+		case Ordinal:
+			if tok.pattern == Naming {
+				// a name used again reuses its position
+				if _, ok := names[tok.name]; !ok {
+					seq++
+					names[tok.name] = seq
+				}
+				buf.WriteString(symbol + strconv.Itoa(names[tok.name]))
+				continue
+			}
+			seq++
+			if tok.pattern == Ordinal {
+				buf.WriteString(symbol + strconv.Itoa(tok.index))
+			} else {
+				buf.WriteString(symbol + strconv.Itoa(seq))
+			}
+		case Naming:
+			switch tok.pattern {
+			case Naming:
+				buf.WriteString(symbol + tok.name)
+			case Ordinal:
+				buf.WriteString(symbol + "param" + strconv.Itoa(tok.index-1))
+			default:
+				buf.WriteString(symbol + "param" + strconv.Itoa(seq))
+				seq++
+			}
+		}
+	}
+	buf.WriteString(sqlText[last:])
+	return buf.String()
+}
+
+// rebindArguments rewrite the bind variables of sqlText in the pattern of args, values are taken from
+// values by position for "?" and "$n", and by name for ":name" and "@name". Every value which is not a
+// sql.NamedArg or NamedParam must be used by a bind variable
+func rebindArguments(sqlText string, values []interface{}, args *sqlArguments) (string, error) {
+	var buf strings.Builder
+	last := 0
+	seq := 0
+	used := make([]bool, len(values))
+	for _, tok := range scanBindTokens(sqlText) {
+		var v interface{}
+		switch tok.pattern {
+		case PlaceHolder:
+			if seq >= len(values) {
+				return "", fmt.Errorf("sql has more bind variables than %d arguments", len(values))
+			}
+			v = values[seq]
+			used[seq] = true
+			seq++
+		case Ordinal:
+			if tok.index < 1 || tok.index > len(values) {
+				return "", fmt.Errorf("bind variable $%d out of %d arguments", tok.index, len(values))
+			}
+			v = values[tok.index-1]
+			used[tok.index-1] = true
+		case Naming:
+			found := false
+			for _, arg := range values {
+				if nam, ok := arg.(sql.NamedArg); ok && nam.Name == tok.name {
+					v, found = nam.Value, true
+					break
+				}
+				if p, ok := arg.(NamedParam); ok && p.Name == tok.name {
+					v, found = p, true
+					break
+				}
+			}
+			if !found {
+				// a value given at execution time
+				v = NamedParam{tok.name}
+			}
+		}
+		buf.WriteString(sqlText[last:tok.start])
+		last = tok.end
+		if args.pattern == Naming && tok.pattern == Naming {
+			if _, ok := v.(NamedParam); !ok {
+				if _, ok := args.GetByName(tok.name); !ok {
+					args.SetNameValue(tok.name, v)
+				}
+				buf.WriteString(args.symbolPrefix + tok.name)
+				continue
+			}
+		}
+		buf.WriteString(args.Set(v))
+	}
+	for i, v := range values {
+		switch v.(type) {
+		case sql.NamedArg, NamedParam:
+			continue
+		}
+		if !used[i] {
+			return "", fmt.Errorf("argument %d is not used by a bind variable of the sql", i+1)
+		}
+	}
+	buf.WriteString(sqlText[last:])
+	return buf.String(), nil
+}
+
+// bindFragment bind the "?" markers of a raw expression to args, in the pattern of args. An expression
+// without bindings is kept as written, its "?" may be an operator like the jsonb "?" of PostgreSQL
+func bindFragment(expression string, bindings []interface{}, args *sqlArguments) (string, error) {
+	if len(bindings) == 0 {
+		return expression, nil
+	}
+	var buf strings.Builder
+	last := 0
+	seq := 0
+	for _, tok := range scanBindTokens(expression) {
+		if tok.pattern != PlaceHolder {
+			continue
+		}
+		if seq >= len(bindings) {
+			return "", fmt.Errorf("expression %q has more markers than %d bindings", expression, len(bindings))
+		}
+		buf.WriteString(expression[last:tok.start])
+		buf.WriteString(args.Set(bindings[seq]))
+		last = tok.end
+		seq++
+	}
+	if seq != len(bindings) {
+		return "", fmt.Errorf("expression %q has %d markers, got %d bindings", expression, seq, len(bindings))
+	}
+	buf.WriteString(expression[last:])
+	return buf.String(), nil
+}
@@ -0,0 +1,85 @@
+package gqbuilder
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+)
+
+func TestRebind(t *testing.T) {
+	cases := []struct {
+		sql    string
+		from   bindPattern
+		to     bindPattern
+		expect string
+	}{
+		{"SELECT * FROM t WHERE a = ? AND b = '?' AND c = ?", PlaceHolder, Ordinal,
+			"SELECT * FROM t WHERE a = $1 AND b = '?' AND c = $2"},
+		{`SELECT "a?" FROM t -- why?` + "\n" + `WHERE x::int = ? /* ? */`, PlaceHolder, Ordinal,
+			`SELECT "a?" FROM t -- why?` + "\n" + `WHERE x::int = $1 /* ? */`},
+		{"SELECT * FROM t WHERE a = $1 AND b = $2", Ordinal, PlaceHolder,
+			"SELECT * FROM t WHERE a = ? AND b = ?"},
+		{"SELECT * FROM t WHERE a = :min AND b < :max AND c > :min AND d = @@version", Naming, Ordinal,
+			"SELECT * FROM t WHERE a = $1 AND b < $2 AND c > $1 AND d = @@version"},
+		{"SELECT * FROM t WHERE a = @min AND 'it''s :x' = ?", Naming, PlaceHolder,
+			"SELECT * FROM t WHERE a = ? AND 'it''s :x' = ?"},
+		{"SELECT * FROM t WHERE a = ? AND b = ?", PlaceHolder, Naming,
+			"SELECT * FROM t WHERE a = :param0 AND b = :param1"},
+	}
+	for _, c := range cases {
+		if s := Rebind(c.sql, c.from, c.to); s != c.expect {
+			t.Errorf("test rebind: unexpected sql %q from %q\n", s, c.sql)
+		}
+	}
+	if s := RebindSymbol("a = ?", PlaceHolder, Naming, "@"); s != "a = @param0" {
+		t.Errorf("test rebind symbol: unexpected sql %q\n", s)
+	}
+}
+
+func TestRaw(t *testing.T) {
+	bdr := NewBuilder(PostgreSQL, nil)
+	rst, err := bdr.Raw("SELECT * FROM users WHERE age > ? AND name <> 'a?' AND created::date = ?", 20, "2020-01-01")
+	if err != nil {
+		t.Errorf("test raw error: %s\n", err)
+		return
+	}
+	raw, args := rst.ToPrepared()
+	if raw != "SELECT * FROM users WHERE age > $1 AND name <> 'a?' AND created::date = $2" || len(args) != 2 {
+		t.Errorf("test raw: unexpected sql %s %v\n", raw, args)
+	}
+	if s, _ := rst.ToString(); s != "SELECT * FROM users WHERE age > 20 AND name <> 'a?' AND created::date = '2020-01-01'" {
+		t.Errorf("test raw: unexpected string %s\n", s)
+	}
+
+	rst, err = bdr.Raw("SELECT * FROM users WHERE age > :min AND score > :min", sql.Named("min", 10))
+	if err != nil {
+		t.Errorf("test raw error: %s\n", err)
+		return
+	}
+	raw, args = rst.ToPrepared()
+	if raw != "SELECT * FROM users WHERE age > $1 AND score > $2" || len(args) != 2 || args[1] != 10 {
+		t.Errorf("test raw: unexpected named sql %s %v\n", raw, args)
+	}
+	if _, err = bdr.Raw("SELECT ?", 1, 2); err == nil {
+		t.Errorf("test raw: expect an error for unused arguments\n")
+	}
+
+	rst, err = bdr.Raw("SELECT * FROM users WHERE age > $2 AND score > $1 AND level < $2", 10, 20)
+	if err != nil {
+		t.Errorf("test raw error: %s\n", err)
+		return
+	}
+	if raw, args = rst.ToPrepared(); raw != "SELECT * FROM users WHERE age > $1 AND score > $2 AND level < $3" ||
+		fmt.Sprint(args) != "[20 10 20]" {
+		t.Errorf("test raw: unexpected ordinal sql %s %v\n", raw, args)
+	}
+	if _, err = bdr.Raw("SELECT * FROM users WHERE a = $1", 1, 2); err == nil {
+		t.Errorf("test raw: expect an error for an argument unused by $n\n")
+	}
+	if _, err = bdr.Raw("SELECT * FROM users WHERE a = $2", 1, 2); err == nil {
+		t.Errorf("test raw: expect an error for an argument skipped by $n\n")
+	}
+	if _, err = bdr.Raw("SELECT * FROM users", 1); err == nil {
+		t.Errorf("test raw: expect an error for arguments without bind variables\n")
+	}
+}
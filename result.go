@@ -8,10 +8,20 @@ import (
 	"time"
 )
 
+// NamedParam is a placeholder whose value is given by Params when the statement is executed
+type NamedParam struct {
+	Name string
+}
+
+// Params are the values of named parameters
+type Params map[string]interface{}
+
 type sqlArguments struct {
 	values       []interface{}
 	symbolPrefix string
 	pattern      bindPattern
+	// collision is a named parameter whose name was taken by a generated name
+	collision string
 }
 
 func newSQLArguments(bp bindPattern, symbol string) *sqlArguments {
@@ -23,6 +33,9 @@ func newSQLArguments(bp bindPattern, symbol string) *sqlArguments {
 }
 
 func (s *sqlArguments) Set(value interface{}) string {
+	if p, ok := value.(NamedParam); ok {
+		return s.setParam(p)
+	}
 	switch s.pattern {
 	case PlaceHolder:
 		s.values = append(s.values, value)
@@ -37,6 +50,11 @@ func (s *sqlArguments) Set(value interface{}) string {
 			return s.symbolPrefix + nam.Name
 		}
 		nam := "param" + strconv.Itoa(p)
+		for _, taken := s.GetByName(nam); taken; _, taken = s.GetByName(nam) {
+			// skip names of named parameters
+			p++
+			nam = "param" + strconv.Itoa(p)
+		}
 		s.values = append(s.values, sql.Named(nam, value))
 		return s.symbolPrefix + nam
 		
@@ -46,6 +64,32 @@ func (s *sqlArguments) Set(value interface{}) string {
 	}
 }
 
+// setParam bind a named parameter, a name used again reuses its placeholder when the pattern allows
+func (s *sqlArguments) setParam(p NamedParam) string {
+	switch s.pattern {
+	case Naming:
+		if v, ok := s.GetByName(p.Name); ok {
+			if v != p {
+				// a value bound before got this generated name
+				s.collision = p.Name
+			}
+			return s.symbolPrefix + p.Name
+		}
+		return s.SetNameValue(p.Name, p)
+	case Ordinal:
+		for i, v := range s.values {
+			if v == p {
+				return s.symbolPrefix + strconv.Itoa(i+1)
+			}
+		}
+		s.values = append(s.values, p)
+		return s.symbolPrefix + strconv.Itoa(len(s.values))
+	default:
+		s.values = append(s.values, p)
+		return s.symbolPrefix
+	}
+}
+
 func (s *sqlArguments) SetNameValue(name string, value interface{}) string {
 	s.values = append(s.values, sql.Named(name, value))
 	return s.symbolPrefix + name
@@ -53,6 +97,7 @@ func (s *sqlArguments) SetNameValue(name string, value interface{}) string {
 
 func (s *sqlArguments) Clean() {
 	s.values = make([]interface{}, 0, 16)
+	s.collision = ""
 }
 
 func (s *sqlArguments) Len() int {
@@ -100,97 +145,64 @@ func (s *SQLResult) ToString() (string, error) {
 	if s.sql != "" {
 		return s.sql, nil
 	}
-	ssql := s.rawSQL
-	n := s.args.Len()
-	switch s.args.pattern {
-	case PlaceHolder:
-		for i := 0; i < n; i++ {
-			refv := s.args.GetByIndex(i)
-			if v, ok := refv.(string); ok {
-				v = "'" + v + "'"
-				ssql = strings.Replace(ssql, s.args.symbolPrefix, v, 1)
-				continue
-			}
-			if v, ok := refv.(bool); ok {
-				if v {
-					ssql = strings.Replace(ssql, s.args.symbolPrefix, "TRUE", 1)
-				} else {
-					ssql = strings.Replace(ssql, s.args.symbolPrefix, "FALSE", 1)
-				}
-				continue
-			}
-			if v, ok := s.numberToString(refv); ok {
-				ssql = strings.Replace(ssql, s.args.symbolPrefix, v, 1)
-				continue
-			}
-			if v, ok := refv.(time.Time); ok {
-				ssql = strings.Replace(ssql, s.args.symbolPrefix, v.String(), 1)
-				continue
-			}
-			return "", fmt.Errorf("argument %v(%T) can not be coverted to string", refv, refv)
+	var buf strings.Builder
+	last := 0
+	seq := 0
+	for _, tok := range scanBindTokens(s.rawSQL) {
+		if tok.pattern != s.args.pattern {
+			continue
 		}
-	case Ordinal:
-		for i := 0; i < n; i++ {
-			refv := s.args.GetByIndex(i)
-			old := s.args.symbolPrefix + strconv.Itoa(i+1)
-			if p, ok := refv.(string); ok {
-				p = "'" + p + "'"
-				ssql = strings.Replace(ssql, old, p, 1)
-				continue
+		var refv interface{}
+		switch tok.pattern {
+		case PlaceHolder:
+			if seq >= s.args.Len() {
+				return "", fmt.Errorf("bind variable %d has no argument", seq+1)
 			}
-			if p, ok := refv.(bool); ok {
-				if p {
-					ssql = strings.Replace(ssql, old, "TRUE", 1)
-
-				} else {
-					ssql = strings.Replace(ssql, old, "FALSE", 1)
-				}
-				continue
+			refv = s.args.GetByIndex(seq)
+			seq++
+		case Ordinal:
+			if tok.index < 1 || tok.index > s.args.Len() {
+				return "", fmt.Errorf("bind variable $%d has no argument", tok.index)
 			}
-			if p, ok := s.numberToString(refv); ok {
-				ssql = strings.Replace(ssql, old, p, 1)
+			refv = s.args.GetByIndex(tok.index - 1)
+		case Naming:
+			v, ok := s.args.GetByName(tok.name)
+			if !ok {
 				continue
 			}
-			if v, ok := refv.(time.Time); ok {
-				ssql = strings.Replace(ssql, old, v.String(), 1)
-				continue
-			}	
-			return "", fmt.Errorf("argument %v(%T) can not be coverted to string", refv, refv)
-			
+			refv = v
 		}
-	case Naming:
-		for i := 0; i < n; i++ {
-			refv := s.args.GetByIndex(i)
-			if nv, ok := refv.(sql.NamedArg); ok {
-				old := s.args.symbolPrefix + nv.Name
-				if v, ok := nv.Value.(string); ok {
-					v = "'" + v + "'"
-					ssql = strings.Replace(ssql, old, v, 1)
-					continue
-				}
-				if v, ok := nv.Value.(bool); ok {
-					if v {
-						ssql = strings.Replace(ssql, old, "TRUE", 1)
-
-					} else {
-						ssql = strings.Replace(ssql, old, "FALSE", 1)
-					}
-					continue
-				}
-				if v, ok := s.numberToString(nv.Value); ok {
-					ssql = strings.Replace(ssql, old, v, 1)
-					continue
-				}
-				if v, ok := refv.(time.Time); ok {
-					ssql = strings.Replace(ssql, old, v.String(), 1)
-					continue
-				}
-				return "", fmt.Errorf("argument %#v(%T) can not be coverted to string", refv, refv)
-			}
+		v, err := s.literal(refv)
+		if err != nil {
+			return "", err
 		}
+		buf.WriteString(s.rawSQL[last:tok.start])
+		buf.WriteString(v)
+		last = tok.end
 	}
-	s.sql = ssql
-	return ssql, nil
+	buf.WriteString(s.rawSQL[last:])
+	s.sql = buf.String()
+	return s.sql, nil
+}
+
+// literal convert a bind variable to a sql literal
+func (s *SQLResult) literal(refv interface{}) (string, error) {
+	if v, ok := refv.(string); ok {
+		return "'" + strings.Replace(v, "'", "''", -1) + "'", nil
+	}
+	if v, ok := refv.(bool); ok {
+		if v {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	}
+	if v, ok := s.numberToString(refv); ok {
+		return v, nil
+	}
+	if v, ok := refv.(time.Time); ok {
+		return v.String(), nil
+	}
+	return "", fmt.Errorf("argument %v(%T) can not be coverted to string", refv, refv)
 }
 
 // ToPrepared convert result to a sql statment with placeholders, and a bind variables list
@@ -198,6 +210,43 @@ func (s *SQLResult) ToPrepared() (string, []interface{}) {
 	return s.rawSQL, s.args.values
 }
 
+// Args return the bind variables list, named parameters are replaced by their values in params. A later
+// Params overrides the values of a former one
+func (s *SQLResult) Args(params ...Params) ([]interface{}, error) {
+	values := make([]interface{}, 0, s.args.Len())
+	for _, v := range s.args.values {
+		switch arg := v.(type) {
+		case NamedParam:
+			pv, err := s.paramValue(arg.Name, params)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, pv)
+		case sql.NamedArg:
+			if p, ok := arg.Value.(NamedParam); ok {
+				pv, err := s.paramValue(p.Name, params)
+				if err != nil {
+					return nil, err
+				}
+				arg = sql.Named(arg.Name, pv)
+			}
+			values = append(values, arg)
+		default:
+			values = append(values, v)
+		}
+	}
+	return values, nil
+}
+
+func (s *SQLResult) paramValue(name string, params []Params) (interface{}, error) {
+	for i := len(params) - 1; i >= 0; i-- {
+		if v, ok := params[i][name]; ok {
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("no value for parameter %s", name)
+}
+
 func (s *SQLResult) numberToString(any interface{}) (string, bool) {
 	switch v := any.(type) {
 	case int:
@@ -0,0 +1,402 @@
+package gqbuilder
+
+/*
+	build DDL statements: CREATE, ALTER and DROP TABLE, indexes and foreign keys
+*/
+
+import (
+	"context"
+	"strings"
+)
+
+// Schema builds the DDL statements of a builder's database
+type Schema struct {
+	builder *Builder
+}
+
+// Schema return the DDL builder
+func (b *Builder) Schema() *Schema {
+	return &Schema{builder: b}
+}
+
+// SchemaStatement is one or more DDL statements, CREATE TABLE is followed by the CREATE INDEX statements of
+// its indexes
+type SchemaStatement struct {
+	builder *Builder
+	build   func(c *baseCompiler) ([]string, error)
+}
+
+// Compile return the sql statements in the order they are executed
+func (s *SchemaStatement) Compile() ([]string, error) {
+	return s.build(s.builder.cmpl.base())
+}
+
+// Exec execute the statements one by one on the builder's database, it stops at the first error
+func (s *SchemaStatement) Exec(ctx context.Context) error {
+	return s.ExecWith(ctx, s.builder.pool)
+}
+
+// ExecWith execute the statements one by one on ex, e.g. a transaction
+func (s *SchemaStatement) ExecWith(ctx context.Context, ex Executor) error {
+	stmts, err := s.Compile()
+	if err != nil {
+		return err
+	}
+	for _, stmt := range stmts {
+		err := s.builder.observe(ctx, opExec, stmtInfo{method: "ddl"}, stmt, nil, func(ctx context.Context) (int64, error) {
+			res, err := ex.ExecContext(ctx, stmt)
+			return rowsAffected(res, err), err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Schema) statement(build func(c *baseCompiler) ([]string, error)) *SchemaStatement {
+	return &SchemaStatement{builder: s.builder, build: build}
+}
+
+// CreateTable return the CREATE TABLE statement of the table defined by define
+func (s *Schema) CreateTable(name string, define func(t *TableDef)) *SchemaStatement {
+	t := newTableDef(name)
+	define(t)
+	return s.statement(func(c *baseCompiler) ([]string, error) {
+		return c.compileCreateTable(t, false)
+	})
+}
+
+// CreateTableIfNotExists is CreateTable with IF NOT EXISTS, indexes are created the same way
+func (s *Schema) CreateTableIfNotExists(name string, define func(t *TableDef)) *SchemaStatement {
+	t := newTableDef(name)
+	define(t)
+	return s.statement(func(c *baseCompiler) ([]string, error) {
+		return c.compileCreateTable(t, true)
+	})
+}
+
+// AlterTable return the ALTER TABLE statements of the changes made by define: columns added, dropped and
+// renamed, indexes and foreign keys created or dropped
+func (s *Schema) AlterTable(name string, define func(t *TableDef)) *SchemaStatement {
+	t := newTableDef(name)
+	define(t)
+	return s.statement(func(c *baseCompiler) ([]string, error) {
+		return c.compileAlterTable(t)
+	})
+}
+
+// DropTable return the DROP TABLE statement
+func (s *Schema) DropTable(name string) *SchemaStatement {
+	return s.statement(func(c *baseCompiler) ([]string, error) {
+		return []string{kwDROPTABLE + kwSPACE + c.wrapWord(name)}, nil
+	})
+}
+
+// DropTableIfExists return the DROP TABLE IF EXISTS statement
+func (s *Schema) DropTableIfExists(name string) *SchemaStatement {
+	return s.statement(func(c *baseCompiler) ([]string, error) {
+		return []string{kwDROPTABLE + kwSPACE + kwIFEXISTS + kwSPACE + c.wrapWord(name)}, nil
+	})
+}
+
+// RenameTable return the statement renaming table from to to
+func (s *Schema) RenameTable(from, to string) *SchemaStatement {
+	return s.statement(func(c *baseCompiler) ([]string, error) {
+		if c.engine == MySQL {
+			return []string{"RENAME TABLE " + c.wrapWord(from) + " TO " + c.wrapWord(to)}, nil
+		}
+		return []string{kwALTERTABLE + kwSPACE + c.wrapWord(from) + kwSPACE + kwRENAMETO + kwSPACE + c.wrapWord(to)}, nil
+	})
+}
+
+// CreateIndex return the CREATE INDEX statement of columns, the index is named "table_column_index"
+func (s *Schema) CreateIndex(table string, columns ...string) *SchemaStatement {
+	idx := indexDef{name: indexName(table, columns, "index"), columns: columns}
+	return s.statement(func(c *baseCompiler) ([]string, error) {
+		return []string{c.compileCreateIndex(table, idx)}, nil
+	})
+}
+
+// CreateUniqueIndex return the CREATE UNIQUE INDEX statement of columns, the index is named
+// "table_column_unique"
+func (s *Schema) CreateUniqueIndex(table string, columns ...string) *SchemaStatement {
+	idx := indexDef{name: indexName(table, columns, "unique"), columns: columns, unique: true}
+	return s.statement(func(c *baseCompiler) ([]string, error) {
+		return []string{c.compileCreateIndex(table, idx)}, nil
+	})
+}
+
+// DropIndex return the DROP INDEX statement of the index name of table
+func (s *Schema) DropIndex(table, name string) *SchemaStatement {
+	return s.statement(func(c *baseCompiler) ([]string, error) {
+		return []string{c.compileDropIndex(table, name)}, nil
+	})
+}
+
+// TableDef defines the columns, indexes and foreign keys of a table
+type TableDef struct {
+	name     string
+	columns  []*ColumnDef
+	primary  []string
+	indexes  []indexDef
+	foreigns []*ForeignKeyDef
+	drops    []string
+	renames  [][2]string
+	dropIdx  []string
+	dropFks  []string
+}
+
+func newTableDef(name string) *TableDef {
+	return &TableDef{name: name}
+}
+
+type indexDef struct {
+	name    string
+	columns []string
+	unique  bool
+}
+
+// indexName return the default name of an index, e.g. "users_email_unique"
+func indexName(table string, columns []string, suffix string) string {
+	parts := append([]string{strings.Replace(table, ".", "_", -1)}, columns...)
+	return strings.ToLower(strings.Join(append(parts, suffix), "_"))
+}
+
+func (t *TableDef) column(name string, kind columnKind) *ColumnDef {
+	col := &ColumnDef{name: name, kind: kind}
+	t.columns = append(t.columns, col)
+	return col
+}
+
+// ID add an auto-increment big integer primary key "id"
+func (t *TableDef) ID() *ColumnDef {
+	return t.BigIncrements("id")
+}
+
+// Increments add an auto-increment integer primary key
+func (t *TableDef) Increments(name string) *ColumnDef {
+	col := t.column(name, integerColumn)
+	col.autoIncrement = true
+	col.primary = true
+	return col
+}
+
+// BigIncrements add an auto-increment big integer primary key
+func (t *TableDef) BigIncrements(name string) *ColumnDef {
+	col := t.column(name, bigIntegerColumn)
+	col.autoIncrement = true
+	col.primary = true
+	return col
+}
+
+// Integer add an INTEGER column
+func (t *TableDef) Integer(name string) *ColumnDef {
+	return t.column(name, integerColumn)
+}
+
+// BigInteger add a BIGINT column
+func (t *TableDef) BigInteger(name string) *ColumnDef {
+	return t.column(name, bigIntegerColumn)
+}
+
+// SmallInteger add a SMALLINT column
+func (t *TableDef) SmallInteger(name string) *ColumnDef {
+	return t.column(name, smallIntegerColumn)
+}
+
+// String add a VARCHAR column of length characters
+func (t *TableDef) String(name string, length int) *ColumnDef {
+	col := t.column(name, stringColumn)
+	col.length = length
+	return col
+}
+
+// Text add a TEXT column
+func (t *TableDef) Text(name string) *ColumnDef {
+	return t.column(name, textColumn)
+}
+
+// Boolean add a boolean column, TINYINT(1) on MySQL
+func (t *TableDef) Boolean(name string) *ColumnDef {
+	return t.column(name, booleanColumn)
+}
+
+// Float add a double precision column
+func (t *TableDef) Float(name string) *ColumnDef {
+	return t.column(name, floatColumn)
+}
+
+// Decimal add a DECIMAL(precision, scale) column
+func (t *TableDef) Decimal(name string, precision, scale int) *ColumnDef {
+	col := t.column(name, decimalColumn)
+	col.precision = precision
+	col.scale = scale
+	return col
+}
+
+// Date add a DATE column
+func (t *TableDef) Date(name string) *ColumnDef {
+	return t.column(name, dateColumn)
+}
+
+// DateTime add a date and time column, TIMESTAMP on PostgreSQL and DATETIME elsewhere
+func (t *TableDef) DateTime(name string) *ColumnDef {
+	return t.column(name, dateTimeColumn)
+}
+
+// Timestamps add nullable "created_at" and "updated_at" date and time columns
+func (t *TableDef) Timestamps() {
+	t.DateTime("created_at")
+	t.DateTime("updated_at")
+}
+
+// JSON add a JSON column, JSONB on PostgreSQL and TEXT on SQLite
+func (t *TableDef) JSON(name string) *ColumnDef {
+	return t.column(name, jsonColumn)
+}
+
+// Binary add a binary column, BYTEA on PostgreSQL and BLOB elsewhere
+func (t *TableDef) Binary(name string) *ColumnDef {
+	return t.column(name, binaryColumn)
+}
+
+// UUID add a UUID column, CHAR(36) on MySQL and TEXT on SQLite
+func (t *TableDef) UUID(name string) *ColumnDef {
+	return t.column(name, uuidColumn)
+}
+
+// Primary set the primary key of the table to columns
+func (t *TableDef) Primary(columns ...string) {
+	t.primary = columns
+}
+
+// Index add an index of columns named "table_column_index"
+func (t *TableDef) Index(columns ...string) {
+	t.indexes = append(t.indexes, indexDef{name: indexName(t.name, columns, "index"), columns: columns})
+}
+
+// Unique add a unique index of columns named "table_column_unique"
+func (t *TableDef) Unique(columns ...string) {
+	t.indexes = append(t.indexes, indexDef{name: indexName(t.name, columns, "unique"), columns: columns, unique: true})
+}
+
+// Foreign add a foreign key of columns named "table_column_foreign", call References on it
+func (t *TableDef) Foreign(columns ...string) *ForeignKeyDef {
+	fk := &ForeignKeyDef{name: indexName(t.name, columns, "foreign"), columns: columns}
+	t.foreigns = append(t.foreigns, fk)
+	return fk
+}
+
+// DropColumn drop a column, it is only used by AlterTable
+func (t *TableDef) DropColumn(name string) {
+	t.drops = append(t.drops, name)
+}
+
+// RenameColumn rename a column, it is only used by AlterTable
+func (t *TableDef) RenameColumn(from, to string) {
+	t.renames = append(t.renames, [2]string{from, to})
+}
+
+// DropIndex drop an index by name, it is only used by AlterTable
+func (t *TableDef) DropIndex(name string) {
+	t.dropIdx = append(t.dropIdx, name)
+}
+
+// DropForeign drop a foreign key by name, it is only used by AlterTable
+func (t *TableDef) DropForeign(name string) {
+	t.dropFks = append(t.dropFks, name)
+}
+
+// ColumnDef defines a column, columns are nullable unless NotNull is called
+type ColumnDef struct {
+	name          string
+	kind          columnKind
+	length        int
+	precision     int
+	scale         int
+	notNull       bool
+	unique        bool
+	primary       bool
+	autoIncrement bool
+	unsigned      bool
+	hasDefault    bool
+	defaultValue  interface{}
+	defaultRaw    string
+}
+
+// NotNull add NOT NULL to the column
+func (c *ColumnDef) NotNull() *ColumnDef {
+	c.notNull = true
+	return c
+}
+
+// Unique add UNIQUE to the column
+func (c *ColumnDef) Unique() *ColumnDef {
+	c.unique = true
+	return c
+}
+
+// Primary make the column the primary key
+func (c *ColumnDef) Primary() *ColumnDef {
+	c.primary = true
+	return c
+}
+
+// Unsigned make an integer column UNSIGNED, it only takes effect on MySQL
+func (c *ColumnDef) Unsigned() *ColumnDef {
+	c.unsigned = true
+	return c
+}
+
+// Default set the default value of the column, it is written as a sql literal. A time.Time is written as
+// its quoted date, or date and time without zone, and is only accepted by date, time and string columns
+func (c *ColumnDef) Default(value interface{}) *ColumnDef {
+	c.hasDefault = true
+	c.defaultValue = value
+	c.defaultRaw = ""
+	return c
+}
+
+// DefaultRaw set the default value of the column to an expression, e.g. "CURRENT_TIMESTAMP"
+func (c *ColumnDef) DefaultRaw(expression string) *ColumnDef {
+	c.hasDefault = true
+	c.defaultValue = nil
+	c.defaultRaw = expression
+	return c
+}
+
+// ForeignKeyDef defines a foreign key
+type ForeignKeyDef struct {
+	name       string
+	columns    []string
+	refTable   string
+	refColumns []string
+	onDelete   string
+	onUpdate   string
+}
+
+// Name replace the default name of the foreign key
+func (f *ForeignKeyDef) Name(name string) *ForeignKeyDef {
+	f.name = name
+	return f
+}
+
+// References set the table and columns the foreign key references
+func (f *ForeignKeyDef) References(table string, columns ...string) *ForeignKeyDef {
+	f.refTable = table
+	f.refColumns = columns
+	return f
+}
+
+// OnDelete set the action on delete, e.g. "CASCADE" or "SET NULL"
+func (f *ForeignKeyDef) OnDelete(action string) *ForeignKeyDef {
+	f.onDelete = action
+	return f
+}
+
+// OnUpdate set the action on update
+func (f *ForeignKeyDef) OnUpdate(action string) *ForeignKeyDef {
+	f.onUpdate = action
+	return f
+}
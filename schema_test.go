@@ -0,0 +1,109 @@
+package gqbuilder
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCreateTable(t *testing.T) {
+	var con *sql.DB
+	expects := map[databaseType][]string{
+		PostgreSQL: {
+			`CREATE TABLE "users" ("id" BIGSERIAL PRIMARY KEY, "name" VARCHAR(255) NOT NULL, "active" BOOLEAN DEFAULT TRUE, ` +
+				`"team_id" INTEGER, CONSTRAINT "users_team_id_foreign" FOREIGN KEY ("team_id") REFERENCES "teams" ("id") ON DELETE CASCADE)`,
+			`CREATE INDEX "users_name_index" ON "users" ("name")`,
+		},
+		MySQL: {
+			"CREATE TABLE `users` (`id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY, `name` VARCHAR(255) NOT NULL, " +
+				"`active` TINYINT(1) DEFAULT TRUE, `team_id` INT UNSIGNED, " +
+				"CONSTRAINT `users_team_id_foreign` FOREIGN KEY (`team_id`) REFERENCES `teams` (`id`) ON DELETE CASCADE)",
+			"CREATE INDEX `users_name_index` ON `users` (`name`)",
+		},
+		SQLite: {
+			`CREATE TABLE "users" ("id" INTEGER PRIMARY KEY AUTOINCREMENT, "name" VARCHAR(255) NOT NULL, "active" BOOLEAN DEFAULT TRUE, ` +
+				`"team_id" INTEGER, CONSTRAINT "users_team_id_foreign" FOREIGN KEY ("team_id") REFERENCES "teams" ("id") ON DELETE CASCADE)`,
+			`CREATE INDEX "users_name_index" ON "users" ("name")`,
+		},
+	}
+	for driver, expect := range expects {
+		bdr := NewBuilder(driver, con)
+		stmts, e := bdr.Schema().CreateTable("users", func(t *TableDef) {
+			t.ID().Unsigned()
+			t.String("name", 255).NotNull()
+			t.Boolean("active").Default(true)
+			t.Integer("team_id").Unsigned()
+			t.Foreign("team_id").References("teams", "id").OnDelete("cascade")
+			t.Index("name")
+		}).Compile()
+		if e != nil {
+			t.Errorf("test create table error: %s\n", e)
+			continue
+		}
+		if strings.Join(stmts, ";\n") != strings.Join(expect, ";\n") {
+			t.Errorf("test create table %s: unexpected sql\n%s\n", driver, strings.Join(stmts, ";\n"))
+		}
+	}
+}
+
+func TestAlterTable(t *testing.T) {
+	var con *sql.DB
+	bdr := NewBuilder(MySQL, con)
+	stmts, e := bdr.Schema().AlterTable("users", func(t *TableDef) {
+		t.DateTime("deleted_at")
+		t.RenameColumn("name", "full_name")
+		t.DropIndex("users_name_index")
+		t.DropColumn("active")
+		t.Unique("email")
+	}).Compile()
+	expect := []string{
+		"ALTER TABLE `users` ADD COLUMN `deleted_at` DATETIME",
+		"ALTER TABLE `users` RENAME COLUMN `name` TO `full_name`",
+		"DROP INDEX `users_name_index` ON `users`",
+		"ALTER TABLE `users` DROP COLUMN `active`",
+		"CREATE UNIQUE INDEX `users_email_unique` ON `users` (`email`)",
+	}
+	if e != nil || strings.Join(stmts, ";\n") != strings.Join(expect, ";\n") {
+		t.Errorf("test alter table: unexpected sql %v %v\n", stmts, e)
+	}
+
+	stmts, _ = NewBuilder(PostgreSQL, con).Schema().DropTableIfExists("users").Compile()
+	if len(stmts) != 1 || stmts[0] != `DROP TABLE IF EXISTS "users"` {
+		t.Errorf("test drop table: unexpected sql %v\n", stmts)
+	}
+
+	_, e = NewBuilder(SQLite, con).Schema().AlterTable("users", func(t *TableDef) {
+		t.Foreign("team_id").References("teams", "id")
+	}).Compile()
+	if !errors.Is(e, ErrUnsupported) {
+		t.Errorf("test alter table: expect ErrUnsupported for a foreign key on SQLite, got %v\n", e)
+	}
+}
+
+func TestTimeDefault(t *testing.T) {
+	var con *sql.DB
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, driver := range []databaseType{PostgreSQL, MySQL, SQLite} {
+		bdr := NewBuilder(driver, con)
+		stmts, e := bdr.Schema().AlterTable("events", func(t *TableDef) {
+			t.Date("day").Default(at)
+			t.DateTime("starts_at").Default(at)
+		}).Compile()
+		if e != nil || len(stmts) != 2 {
+			t.Errorf("test time default error: %v %v\n", stmts, e)
+			continue
+		}
+		if !strings.HasSuffix(stmts[0], "DATE DEFAULT '2024-01-02'") ||
+			!strings.HasSuffix(stmts[1], " DEFAULT '2024-01-02 03:04:05'") {
+			t.Errorf("test time default %s: unexpected sql %v\n", driver, stmts)
+		}
+	}
+	_, e := NewBuilder(MySQL, con).Schema().CreateTable("events", func(t *TableDef) {
+		t.Integer("n").Default(at)
+	}).Compile()
+	if e == nil {
+		t.Errorf("test time default: expect an error for a time default of an integer column\n")
+	}
+}
@@ -6,9 +6,11 @@ type sqliteCompiler struct {
 
 func newSQLiteCompiler() *sqliteCompiler {
 	c := new(sqliteCompiler)
+	c.engine = SQLite
 	c.paramsPattern = PlaceHolder
 	c.symbolPrefix = "?"
 	c.leftIdentifier = "\""
 	c.righIdentifier = "\""
+	c.conditions = standardConditions{}
 	return c
 }
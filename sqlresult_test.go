@@ -1,6 +1,7 @@
 package gqbuilder
 
 import (
+	"database/sql"
 	"testing"
 	"fmt"
 )
@@ -110,4 +111,63 @@ func TestNaming(t *testing.T) {
 	} else {
 		println(s)
 	}
-}
\ No newline at end of file
+}
+
+func TestNamedParams(t *testing.T) {
+	bdr := NewBuilder(SQLite, nil).WithBindPattern(Naming, ":")
+	q := bdr.Query("user").Select("id")
+	q.Where("age", ">", q.Param("min_age")).Where("score", ">", q.Param("min_age")).Where("status", "=", 1)
+	rst, err := q.Compile()
+	if err != nil {
+		t.Errorf("test named params error: %s\n", err)
+		return
+	}
+	raw, _ := rst.ToPrepared()
+	if raw != `SELECT "id" FROM "user" WHERE "age" > :min_age AND "score" > :min_age AND "status" = :param1` {
+		t.Errorf("test named params: unexpected sql %s\n", raw)
+	}
+	args, err := rst.Args(Params{"min_age": 20})
+	if err != nil {
+		t.Errorf("test named params error: %s\n", err)
+		return
+	}
+	if len(args) != 2 || args[0] != sql.Named("min_age", 20) || args[1] != sql.Named("param1", 1) {
+		t.Errorf("test named params: unexpected args %#v\n", args)
+	}
+	if _, err = rst.Args(); err == nil {
+		t.Errorf("test named params: expect an error for missing parameter\n")
+	}
+
+	pg := NewBuilder(PostgreSQL, nil)
+	q = pg.Query("user").Select("id")
+	q.Where("age", ">", q.Param("min_age")).Where("status", "=", 1).Where("score", ">", q.Param("min_age"))
+	rst, _ = q.Compile()
+	raw, _ = rst.ToPrepared()
+	if raw != `SELECT "id" FROM "user" WHERE "age" > $1 AND "status" = $2 AND "score" > $1` {
+		t.Errorf("test named params: unexpected sql %s\n", raw)
+	}
+	args, _ = rst.Args(Params{"min_age": 30})
+	if len(args) != 2 || args[0] != 30 || args[1] != 1 {
+		t.Errorf("test named params: unexpected args %#v\n", args)
+	}
+}
+
+func TestNamedParamCollision(t *testing.T) {
+	bdr := NewBuilder(SQLite, nil).WithBindPattern(Naming, ":")
+	q := bdr.Query("user").Select("id")
+	q.Where("age", ">", q.Param("param1")).Where("status", "=", 1)
+	raw, _, err := q.ToPrepared()
+	if err != nil {
+		t.Errorf("test named param collision error: %s\n", err)
+		return
+	}
+	if raw != `SELECT "id" FROM "user" WHERE "age" > :param1 AND "status" = :param2` {
+		t.Errorf("test named param collision: unexpected sql %s\n", raw)
+	}
+
+	q = bdr.Query("user").Select("id")
+	q.Where("status", "=", 1).Where("age", ">", q.Param("param0"))
+	if _, _, err = q.ToPrepared(); err == nil {
+		t.Errorf("test named param collision: expect an error for a name taken by a generated one\n")
+	}
+}
@@ -0,0 +1,122 @@
+package gqbuilder
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// StmtCacheStats are the counters of a Builder's statement cache
+type StmtCacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Size      int
+}
+
+// stmtKey is a statement of a database, a cluster builder prepares a statement on every database it runs on
+type stmtKey struct {
+	db  *sql.DB
+	sql string
+}
+
+type stmtEntry struct {
+	key     stmtKey
+	stmt    *sql.Stmt
+	refs    int
+	evicted bool
+}
+
+// stmtCache is a LRU cache of prepared statements keyed by database and sql text. An evicted statement is closed when
+// the last caller using it releases it
+type stmtCache struct {
+	mu      sync.Mutex
+	limit   int
+	order   *list.List
+	entries map[stmtKey]*list.Element
+	stats   StmtCacheStats
+}
+
+func newStmtCache(limit int) *stmtCache {
+	c := new(stmtCache)
+	c.limit = limit
+	c.order = list.New()
+	c.entries = make(map[stmtKey]*list.Element, limit)
+	return c
+}
+
+// acquire return the prepared statement of query, call release when the statement isn't used anymore
+func (c *stmtCache) acquire(ctx context.Context, db *sql.DB, query string) (*stmtEntry, error) {
+	key := stmtKey{db, query}
+	c.mu.Lock()
+	if elm, ok := c.entries[key]; ok {
+		c.order.MoveToFront(elm)
+		ent := elm.Value.(*stmtEntry)
+		ent.refs++
+		c.stats.Hits++
+		c.mu.Unlock()
+		return ent, nil
+	}
+	c.stats.Misses++
+	c.mu.Unlock()
+
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elm, ok := c.entries[key]; ok {
+		// prepared by another goroutine meanwhile
+		stmt.Close()
+		ent := elm.Value.(*stmtEntry)
+		ent.refs++
+		return ent, nil
+	}
+	ent := &stmtEntry{key: key, stmt: stmt, refs: 1}
+	c.entries[key] = c.order.PushFront(ent)
+	for c.order.Len() > c.limit {
+		c.evict(c.order.Back())
+	}
+	c.stats.Size = c.order.Len()
+	return ent, nil
+}
+
+func (c *stmtCache) release(ent *stmtEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ent.refs--
+	if ent.evicted && ent.refs == 0 {
+		ent.stmt.Close()
+	}
+}
+
+// evict remove elm from cache, caller must hold the lock
+func (c *stmtCache) evict(elm *list.Element) {
+	ent := elm.Value.(*stmtEntry)
+	c.order.Remove(elm)
+	delete(c.entries, ent.key)
+	ent.evicted = true
+	c.stats.Evictions++
+	if ent.refs == 0 {
+		ent.stmt.Close()
+	}
+}
+
+func (c *stmtCache) snapshot() StmtCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// close evict all statements
+func (c *stmtCache) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for c.order.Len() > 0 {
+		c.evict(c.order.Back())
+	}
+	c.stats.Size = 0
+}
@@ -0,0 +1,247 @@
+package gqbuilder
+
+/*
+	typed tables and columns, their conditions are the same elements the string based methods add
+*/
+
+// TableRef is a typed table name, String() is accepted wherever a table name is
+type TableRef struct {
+	name  string
+	alias string
+}
+
+// Table return the typed table name
+func Table(name string) TableRef {
+	return TableRef{name: name}
+}
+
+// As return the table with an alias, its columns are qualified by the alias
+func (t TableRef) As(alias string) TableRef {
+	t.alias = alias
+	return t
+}
+
+// Name return the table name without alias
+func (t TableRef) Name() string {
+	return t.name
+}
+
+// String return the table name as From and Builder.Query take it, e.g. "users as u"
+func (t TableRef) String() string {
+	if t.alias != "" {
+		return t.name + " as " + t.alias
+	}
+	return t.name
+}
+
+// Col return the column of the table
+func (t TableRef) Col(name string) Column {
+	qualifier := t.name
+	if t.alias != "" {
+		qualifier = t.alias
+	}
+	return Column{table: qualifier, name: name}
+}
+
+// Column is a typed column qualified by its table, String() is accepted wherever a column name is
+type Column struct {
+	table string
+	name  string
+}
+
+// Name return the column name without table
+func (c Column) Name() string {
+	return c.name
+}
+
+// String return the qualified column name, e.g. "users.age"
+func (c Column) String() string {
+	if c.table == "" {
+		return c.name
+	}
+	return c.table + "." + c.name
+}
+
+// As return the column with an alias as Select takes it
+func (c Column) As(alias string) string {
+	return c.String() + " as " + alias
+}
+
+// columnCondition is a built-in condition made by a typed column, it becomes a query element when WhereCond
+// adds it. make return the element with the flags of the query merged in
+type columnCondition struct {
+	make func(flags conditionClause) condition
+}
+
+func (c columnCondition) CompileCondition(ctx *CompileContext) (string, error) {
+	return c.make(conditionClause{}).accept(ctx, ctx.cmpl.conditions)
+}
+
+func (c Column) compare(sign string, value interface{}) Condition {
+	return columnCondition{func(flags conditionClause) condition {
+		var cls compareCondition
+		cls.columnName = c.String()
+		cls.sign = sign
+		cls.value = value
+		cls.conditionClause = flags
+		return cls
+	}}
+}
+
+// Eq is "column = value", value may be a *Query
+func (c Column) Eq(value interface{}) Condition {
+	return c.compare("=", value)
+}
+
+// Ne is "column <> value"
+func (c Column) Ne(value interface{}) Condition {
+	return c.compare("<>", value)
+}
+
+// Gt is "column > value"
+func (c Column) Gt(value interface{}) Condition {
+	return c.compare(">", value)
+}
+
+// Gte is "column >= value"
+func (c Column) Gte(value interface{}) Condition {
+	return c.compare(">=", value)
+}
+
+// Lt is "column < value"
+func (c Column) Lt(value interface{}) Condition {
+	return c.compare("<", value)
+}
+
+// Lte is "column <= value"
+func (c Column) Lte(value interface{}) Condition {
+	return c.compare("<=", value)
+}
+
+// EqCol is "column = other"
+func (c Column) EqCol(other Column) Condition {
+	return columnCondition{func(flags conditionClause) condition {
+		var cls columnCompareCondition
+		cls.leftColumn = c.String()
+		cls.sign = "="
+		cls.rightColumn = other.String()
+		cls.conditionClause = flags
+		return cls
+	}}
+}
+
+func (c Column) like(pattern string, not bool) Condition {
+	return columnCondition{func(flags conditionClause) condition {
+		var cls likeCondition
+		cls.columnName = c.String()
+		cls.like = pattern
+		cls.conditionClause = flags
+		cls.isNot = flags.isNot != not
+		return cls
+	}}
+}
+
+// Like is "column LIKE pattern"
+func (c Column) Like(pattern string) Condition {
+	return c.like(pattern, false)
+}
+
+// NotLike is "column NOT LIKE pattern"
+func (c Column) NotLike(pattern string) Condition {
+	return c.like(pattern, true)
+}
+
+func (c Column) between(from, to interface{}, not bool) Condition {
+	return columnCondition{func(flags conditionClause) condition {
+		var cls betweenCondition
+		cls.columnName = c.String()
+		cls.from = from
+		cls.to = to
+		cls.conditionClause = flags
+		cls.isNot = flags.isNot != not
+		return cls
+	}}
+}
+
+// Between is "column BETWEEN from AND to"
+func (c Column) Between(from, to interface{}) Condition {
+	return c.between(from, to, false)
+}
+
+// NotBetween is "column NOT BETWEEN from AND to"
+func (c Column) NotBetween(from, to interface{}) Condition {
+	return c.between(from, to, true)
+}
+
+func (c Column) in(members []interface{}, not bool) Condition {
+	return columnCondition{func(flags conditionClause) condition {
+		var cls inCondition
+		cls.columnName = c.String()
+		cls.members = members
+		cls.conditionClause = flags
+		cls.isNot = flags.isNot != not
+		return cls
+	}}
+}
+
+// In is "column IN (members)"
+func (c Column) In(members ...interface{}) Condition {
+	return c.in(members, false)
+}
+
+// NotIn is "column NOT IN (members)"
+func (c Column) NotIn(members ...interface{}) Condition {
+	return c.in(members, true)
+}
+
+func (c Column) inQuery(subQuery *Query, not bool) Condition {
+	return columnCondition{func(flags conditionClause) condition {
+		var cls inQueryCondition
+		cls.columnName = c.String()
+		cls.subQuery = subQuery
+		cls.conditionClause = flags
+		cls.isNot = flags.isNot != not
+		return cls
+	}}
+}
+
+// InQuery is "column IN (sub query)"
+func (c Column) InQuery(subQuery *Query) Condition {
+	return c.inQuery(subQuery, false)
+}
+
+// NotInQuery is "column NOT IN (sub query)"
+func (c Column) NotInQuery(subQuery *Query) Condition {
+	return c.inQuery(subQuery, true)
+}
+
+func (c Column) null(not bool) Condition {
+	return columnCondition{func(flags conditionClause) condition {
+		var cls nullCondition
+		cls.columnName = c.String()
+		cls.conditionClause = flags
+		cls.isNot = flags.isNot != not
+		return cls
+	}}
+}
+
+// IsNull is "column IS NULL"
+func (c Column) IsNull() Condition {
+	return c.null(false)
+}
+
+// IsNotNull is "column IS NOT NULL"
+func (c Column) IsNotNull() Condition {
+	return c.null(true)
+}
+
+// Is is "column = TRUE" or "column = FALSE"
+func (c Column) Is(value bool) Condition {
+	return columnCondition{func(flags conditionClause) condition {
+		var cls booleanCondition
+		cls.columnName = c.String()
+		cls.value = value
+		cls.conditionClause = flags
+		return cls
+	}}
+}